@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// accountRollup aggregates the findings on the buckets of one owner
+type accountRollup struct {
+	findings int
+	buckets  map[string]bool
+	worst    warden.Finding
+}
+
+// bucketRollup aggregates the findings on one bucket, until its owner is
+// known. A bucket's findings can arrive before its ACL names the owner.
+type bucketRollup struct {
+	findings int
+	worst    warden.Finding
+}
+
+// unknownOwner groups the buckets whose ACL couldn't be read
+const unknownOwner = "unknown owner"
+
+var (
+	accountsMu    sync.Mutex
+	bucketRollups = map[string]*bucketRollup{}
+	scanAccount   string
+)
+
+// callerAccount returns the account ID of the credentials in use
+func callerAccount(ctx context.Context) (string, error) {
+	cfg, err := scanner.LoadConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("unable to determine account ID, %v", err)
+	}
+	return aws.ToString(identity.Account), nil
+}
+
+// recordAccountFinding adds a finding to the rollup for its bucket
+func recordAccountFinding(f warden.Finding) {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+
+	rollup := bucketRollups[f.Bucket]
+	if rollup == nil {
+		rollup = &bucketRollup{worst: f}
+		bucketRollups[f.Bucket] = rollup
+	}
+	rollup.findings++
+	if severityRank[f.Severity] > severityRank[rollup.worst.Severity] {
+		rollup.worst = f
+	}
+}
+
+// rollupByOwner groups the bucket rollups by the owner that owner returns
+// for each bucket, with buckets of no known owner under unknownOwner
+func rollupByOwner(owner func(bucket string) string) map[string]*accountRollup {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+
+	byOwner := map[string]*accountRollup{}
+	for bucket, b := range bucketRollups {
+		id := owner(bucket)
+		if id == "" {
+			id = unknownOwner
+		}
+		rollup := byOwner[id]
+		if rollup == nil {
+			rollup = &accountRollup{buckets: map[string]bool{}, worst: b.worst}
+			byOwner[id] = rollup
+		}
+		rollup.findings += b.findings
+		rollup.buckets[bucket] = true
+		if severityRank[b.worst.Severity] > severityRank[rollup.worst.Severity] {
+			rollup.worst = b.worst
+		}
+	}
+	return byOwner
+}
+
+// printAccountRollup writes the totals and the worst finding for each
+// bucket owner to stderr. Owners are the canonical user IDs bucket ACLs
+// disclose, which are stable per account.
+func printAccountRollup() {
+	byOwner := rollupByOwner(scanner.BucketOwner)
+
+	ids := make([]string, 0, len(byOwner))
+	for id := range byOwner {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Fprintf(os.Stderr, "Findings by owning account, scanned as account %s:\n", scanAccount)
+	for _, id := range ids {
+		rollup := byOwner[id]
+		fmt.Fprintf(os.Stderr, "  %s: %d finding(s) across %d bucket(s), worst %s: %s\n",
+			warden.Printable(id), rollup.findings, len(rollup.buckets), rollup.worst.Severity, rollup.worst.Message)
+	}
+}
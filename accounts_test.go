@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestRollupByOwner(t *testing.T) {
+	old := bucketRollups
+	defer func() { bucketRollups = old }()
+	bucketRollups = map[string]*bucketRollup{}
+
+	recordAccountFinding(warden.Finding{Bucket: "a", Severity: "MEDIUM", Message: "read"})
+	recordAccountFinding(warden.Finding{Bucket: "a", Severity: "HIGH", Message: "write"})
+	recordAccountFinding(warden.Finding{Bucket: "b", Severity: "INFORMATIONAL", Message: "exists"})
+	recordAccountFinding(warden.Finding{Bucket: "c", Severity: "LOW", Message: "low"})
+	recordAccountFinding(warden.Finding{Bucket: "d", Severity: "MEDIUM", Message: "private"})
+
+	owners := map[string]string{"a": "alice", "b": "alice", "c": "bob"}
+	byOwner := rollupByOwner(func(bucket string) string { return owners[bucket] })
+
+	if len(byOwner) != 3 {
+		t.Fatalf("rolled up %d owners, want alice, bob and the unknown owner", len(byOwner))
+	}
+	alice := byOwner["alice"]
+	if alice.findings != 3 || len(alice.buckets) != 2 {
+		t.Errorf("alice = %d findings across %d buckets, want 3 across 2", alice.findings, len(alice.buckets))
+	}
+	if alice.worst.Message != "write" {
+		t.Errorf("alice's worst finding = %q, want the HIGH one", alice.worst.Message)
+	}
+	if bob := byOwner["bob"]; bob.worst.Severity != "LOW" {
+		t.Errorf("bob's worst = %q, want LOW", bob.worst.Severity)
+	}
+	if unknown := byOwner[unknownOwner]; unknown == nil || !unknown.buckets["d"] {
+		t.Errorf("bucket d with no known owner isn't under %q", unknownOwner)
+	}
+}
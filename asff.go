@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// asffFinding is the subset of the AWS Security Finding Format accepted by
+// Security Hub's BatchImportFindings
+type asffFinding struct {
+	SchemaVersion string            `json:"SchemaVersion"`
+	Id            string            `json:"Id"`
+	ProductArn    string            `json:"ProductArn"`
+	GeneratorId   string            `json:"GeneratorId"`
+	AwsAccountId  string            `json:"AwsAccountId"`
+	Types         []string          `json:"Types"`
+	CreatedAt     string            `json:"CreatedAt"`
+	UpdatedAt     string            `json:"UpdatedAt"`
+	Severity      asffSeverity      `json:"Severity"`
+	Title         string            `json:"Title"`
+	Description   string            `json:"Description"`
+	Resources     []asffResource    `json:"Resources"`
+	ProductFields map[string]string `json:"ProductFields,omitempty"`
+}
+
+type asffSeverity struct {
+	Label string `json:"Label"`
+}
+
+type asffResource struct {
+	Type      string `json:"Type"`
+	Id        string `json:"Id"`
+	Partition string `json:"Partition"`
+	Region    string `json:"Region,omitempty"`
+}
+
+var (
+	asffMu       sync.Mutex
+	asffFindings = []asffFinding{}
+	asffAccount  string
+	asffRegion   string
+)
+
+// initASFF resolves the account and region that findings are imported into,
+// which Security Hub requires on every finding. They come from the same
+// config as the scan, so -profile, -role-arn and -region apply.
+func initASFF(ctx context.Context) error {
+	cfg, err := scanner.LoadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	account, err := callerAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	asffAccount = account
+	asffRegion = cfg.Region
+	if asffRegion == "" {
+		asffRegion = "us-east-1"
+	}
+	return nil
+}
+
+// ASFF field limits enforced by BatchImportFindings
+const (
+	asffBatchSize      = 100
+	asffTitleMax       = 256
+	asffDescriptionMax = 1024
+)
+
+func recordASFF(f warden.Finding) {
+	converted := newASFFFinding(f, asffAccount, asffRegion, time.Now().UTC())
+
+	asffMu.Lock()
+	asffFindings = append(asffFindings, converted)
+	asffMu.Unlock()
+}
+
+func newASFFFinding(f warden.Finding, account string, region string, now time.Time) asffFinding {
+	resource := asffResource{
+		Type:      "AwsS3Bucket",
+		Id:        "arn:aws:s3:::" + f.Bucket,
+		Partition: "aws",
+		Region:    f.Region,
+	}
+	// an access point is scanned by its ARN, which has no ASFF type of its
+	// own, and its objects are addressed under /object/
+	keyPrefix := "/"
+	if strings.HasPrefix(f.Bucket, "arn:") {
+		resource.Type = "Other"
+		resource.Id = f.Bucket
+		keyPrefix = "/object/"
+	}
+	if f.Key != "" {
+		resource.Type = "AwsS3Object"
+		resource.Id += keyPrefix + f.Key
+	}
+
+	types := []string{"Software and Configuration Checks/AWS Security Best Practices"}
+	if f.Severity != "INFORMATIONAL" {
+		types = append(types, "Effects/Data Exposure")
+	}
+
+	var productFields map[string]string
+	if f.Grantee != "" {
+		productFields = map[string]string{
+			"Grantee":    f.Grantee,
+			"Permission": f.Permission,
+			"Source":     f.Source,
+		}
+		if f.Scope != "" {
+			productFields["Scope"] = f.Scope
+		}
+	}
+	if f.Incomplete {
+		productFields = map[string]string{"Incomplete": "true", "Reason": f.Reason}
+	}
+
+	timestamp := now.Format(time.RFC3339)
+	return asffFinding{
+		SchemaVersion: "2018-10-08",
+		// a stable ID lets a re-import update the existing finding
+		Id:           fmt.Sprintf("s3-warden/%s/%s", f.Kind, resource.Id),
+		ProductArn:   fmt.Sprintf("arn:aws:securityhub:%s:%s:product/%s/default", region, account, account),
+		GeneratorId:  "s3-warden/" + f.Kind,
+		AwsAccountId: account,
+		Types:        types,
+		CreatedAt:    timestamp,
+		UpdatedAt:    timestamp,
+		Severity:     asffSeverity{Label: f.Severity},
+		// long object keys can push the message past the field limits
+		Title:         truncate(f.Message, asffTitleMax),
+		Description:   truncate(f.Message, asffDescriptionMax),
+		Resources:     []asffResource{resource},
+		ProductFields: productFields,
+	}
+}
+
+// truncate shortens s to at most max characters, marking the cut with "..."
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// asffBatchPath numbers each batch file, so findings.json becomes
+// findings-1.json, findings-2.json and so on
+func asffBatchPath(path string, batch int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), batch, ext)
+}
+
+// writeASFF saves the collected findings as JSON arrays of at most 100,
+// the most `aws securityhub batch-import-findings` accepts in one call, and
+// returns the paths written
+func writeASFF(path string) ([]string, error) {
+	asffMu.Lock()
+	defer asffMu.Unlock()
+
+	var written []string
+	for batch, start := 1, 0; start < len(asffFindings) || batch == 1; batch, start = batch+1, start+asffBatchSize {
+		end := start + asffBatchSize
+		if end > len(asffFindings) {
+			end = len(asffFindings)
+		}
+		batchPath := asffBatchPath(path, batch)
+		if err := writeASFFBatch(batchPath, asffFindings[start:end]); err != nil {
+			return written, err
+		}
+		written = append(written, batchPath)
+	}
+	return written, nil
+}
+
+func writeASFFBatch(path string, findings []asffFinding) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
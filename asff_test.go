@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestNewASFFFinding(t *testing.T) {
+	now := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	bucketFinding := newASFFFinding(warden.Finding{
+		Bucket:   "example",
+		Region:   "eu-west-1",
+		Kind:     "public-write",
+		Severity: "HIGH",
+		Message:  "Bucket with public write access found: example",
+	}, "123456789012", "us-east-1", now)
+
+	if want := "arn:aws:securityhub:us-east-1:123456789012:product/123456789012/default"; bucketFinding.ProductArn != want {
+		t.Errorf("ProductArn = %q, want %q", bucketFinding.ProductArn, want)
+	}
+	wantBucket := []asffResource{{Type: "AwsS3Bucket", Id: "arn:aws:s3:::example", Partition: "aws", Region: "eu-west-1"}}
+	if !reflect.DeepEqual(bucketFinding.Resources, wantBucket) {
+		t.Errorf("Resources = %+v, want %+v", bucketFinding.Resources, wantBucket)
+	}
+	if bucketFinding.ProductFields != nil {
+		t.Errorf("ProductFields = %v, want none without a grantee", bucketFinding.ProductFields)
+	}
+	if len(bucketFinding.Types) != 2 {
+		t.Errorf("Types = %v, want best practices and data exposure", bucketFinding.Types)
+	}
+	if bucketFinding.CreatedAt != "2024-02-01T12:00:00Z" {
+		t.Errorf("CreatedAt = %q", bucketFinding.CreatedAt)
+	}
+
+	objectFinding := newASFFFinding(warden.Finding{
+		Bucket:   "example",
+		Key:      "dir/file.txt",
+		Kind:     "object-public-read",
+		Severity: "MEDIUM",
+		Message:  "Object with public read access found: example/dir/file.txt",
+
+		Grantee:    warden.AllUsersURI,
+		Permission: "READ",
+		Source:     "acl",
+	}, "123456789012", "us-east-1", now)
+
+	resource := objectFinding.Resources[0]
+	if resource.Type != "AwsS3Object" || resource.Id != "arn:aws:s3:::example/dir/file.txt" {
+		t.Errorf("object resource = %+v", resource)
+	}
+	accessPoint := "arn:aws:s3:eu-west-1:123456789012:accesspoint/reports"
+	apFinding := newASFFFinding(warden.Finding{Bucket: accessPoint, Kind: "open-listing", Severity: "MEDIUM"}, "123456789012", "us-east-1", now)
+	if resource := apFinding.Resources[0]; resource.Type != "Other" || resource.Id != accessPoint {
+		t.Errorf("access point resource = %+v", resource)
+	}
+	apObject := newASFFFinding(warden.Finding{Bucket: accessPoint, Key: "a.txt", Kind: "object-public-read", Severity: "MEDIUM"}, "123456789012", "us-east-1", now)
+	if resource := apObject.Resources[0]; resource.Type != "AwsS3Object" || resource.Id != accessPoint+"/object/a.txt" {
+		t.Errorf("access point object resource = %+v", resource)
+	}
+
+	wantFields := map[string]string{"Grantee": warden.AllUsersURI, "Permission": "READ", "Source": "acl"}
+	if !reflect.DeepEqual(objectFinding.ProductFields, wantFields) {
+		t.Errorf("ProductFields = %v, want %v", objectFinding.ProductFields, wantFields)
+	}
+	if objectFinding.Id == bucketFinding.Id {
+		t.Errorf("object and bucket findings share Id %q", objectFinding.Id)
+	}
+
+	informational := newASFFFinding(warden.Finding{
+		Bucket:   "example",
+		Kind:     "exists",
+		Severity: "INFORMATIONAL",
+		Message:  "Bucket exists but denies access: example",
+	}, "123456789012", "us-east-1", now)
+
+	if want := []string{"Software and Configuration Checks/AWS Security Best Practices"}; !reflect.DeepEqual(informational.Types, want) {
+		t.Errorf("INFORMATIONAL Types = %v, want %v", informational.Types, want)
+	}
+}
+
+func TestNewASFFFindingTruncatesLongKeys(t *testing.T) {
+	key := strings.Repeat("k", 1024)
+	converted := newASFFFinding(warden.Finding{
+		Bucket:   "example",
+		Key:      key,
+		Kind:     "object-public-read",
+		Severity: "MEDIUM",
+		Message:  "Object with public read access found: example/" + key,
+	}, "123456789012", "us-east-1", time.Now())
+
+	if n := len([]rune(converted.Title)); n != asffTitleMax {
+		t.Errorf("Title length = %d, want %d", n, asffTitleMax)
+	}
+	if n := len([]rune(converted.Description)); n != asffDescriptionMax {
+		t.Errorf("Description length = %d, want %d", n, asffDescriptionMax)
+	}
+	if !strings.HasSuffix(converted.Resources[0].Id, key) {
+		t.Errorf("resource Id lost the full key")
+	}
+}
+
+func TestWriteASFFBatches(t *testing.T) {
+	old := asffFindings
+	defer func() { asffFindings = old }()
+
+	asffFindings = make([]asffFinding, 250)
+	for i := range asffFindings {
+		asffFindings[i] = asffFinding{Id: string(rune('a' + i%26))}
+	}
+
+	path := filepath.Join(t.TempDir(), "findings.json")
+	if _, err := writeASFF(path); err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := map[string]int{"findings-1.json": 100, "findings-2.json": 100, "findings-3.json": 50}
+	for name, want := range sizes {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var batch []asffFinding
+		if err := json.Unmarshal(data, &batch); err != nil {
+			t.Fatal(err)
+		}
+		if len(batch) != want {
+			t.Errorf("%s holds %d findings, want %d", name, len(batch), want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "findings-4.json")); err == nil {
+		t.Errorf("unexpected fourth batch")
+	}
+}
+
+func TestWriteASFFEmpty(t *testing.T) {
+	old := asffFindings
+	defer func() { asffFindings = old }()
+	asffFindings = []asffFinding{}
+
+	path := filepath.Join(t.TempDir(), "findings.json")
+	if _, err := writeASFF(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), "findings-1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "[]" {
+		t.Errorf("empty scan wrote %q, want []", data)
+	}
+}
+
+func TestNewASFFFindingIncomplete(t *testing.T) {
+	f := warden.Finding{Bucket: "b", Kind: "scan-incomplete", Severity: "INFORMATIONAL", Message: "m", Incomplete: true, Reason: "listing the objects failed"}
+	got := newASFFFinding(f, "111111111111", "us-east-1", time.Unix(0, 0))
+	if got.ProductFields["Incomplete"] != "true" || got.ProductFields["Reason"] != "listing the objects failed" {
+		t.Errorf("ProductFields = %v, want the incomplete reason", got.ProductFields)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// cleanBucketName extracts the bucket name from the forms a bucket is
+// usually written in: a bare name, s3://bucket/key, and virtual-hosted
+// (https://bucket.s3.region.amazonaws.com/key) or path-style
+// (https://s3.region.amazonaws.com/bucket/key) URLs. Surrounding whitespace
+// and a trailing slash are trimmed. ARNs are passed through as they are,
+// since the scanner resolves bucket and access point ARNs itself, as is
+// anything that isn't recognised.
+func cleanBucketName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "s3://") {
+		bucket, _, _ := strings.Cut(strings.TrimPrefix(name, "s3://"), "/")
+		return bucket
+	}
+	if strings.HasPrefix(name, "https://") || strings.HasPrefix(name, "http://") {
+		if bucket := bucketFromURL(name); bucket != "" {
+			return bucket
+		}
+	}
+	return strings.TrimSuffix(name, "/")
+}
+
+// bucketFromURL returns the bucket addressed by an S3 URL, or "" if the URL
+// isn't an S3 one
+func bucketFromURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if !strings.HasSuffix(host, ".amazonaws.com") && !strings.HasSuffix(host, ".amazonaws.com.cn") {
+		return ""
+	}
+
+	// path-style, where the host is just the S3 endpoint
+	if strings.HasPrefix(host, "s3.") || strings.HasPrefix(host, "s3-") {
+		bucket, _, _ := strings.Cut(strings.TrimPrefix(parsed.Path, "/"), "/")
+		return bucket
+	}
+
+	// virtual-hosted, where the bucket is everything before the endpoint.
+	// The last match is used as bucket names can themselves contain dots
+	end := strings.LastIndex(host, ".s3.")
+	if dash := strings.LastIndex(host, ".s3-"); dash > end {
+		end = dash
+	}
+	if end <= 0 {
+		return ""
+	}
+	return host[:end]
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestCleanBucketName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"my-bucket", "my-bucket"},
+		{"  my-bucket/ ", "my-bucket"},
+		{"s3://my-bucket", "my-bucket"},
+		{"s3://my-bucket/", "my-bucket"},
+		{"s3://my-bucket/some/key.txt", "my-bucket"},
+		{"https://my-bucket.s3.amazonaws.com", "my-bucket"},
+		{"https://my-bucket.s3.amazonaws.com/some/key.txt", "my-bucket"},
+		{"https://my-bucket.s3.eu-west-1.amazonaws.com/", "my-bucket"},
+		{"https://my-bucket.s3-eu-west-1.amazonaws.com", "my-bucket"},
+		{"https://my.dotted.bucket.s3.dualstack.us-east-1.amazonaws.com", "my.dotted.bucket"},
+		{"http://my-bucket.s3-website-us-east-1.amazonaws.com", "my-bucket"},
+		{"https://s3.amazonaws.com/my-bucket", "my-bucket"},
+		{"https://s3.eu-west-1.amazonaws.com/my-bucket/some/key.txt", "my-bucket"},
+		{"https://s3-eu-west-1.amazonaws.com/my-bucket", "my-bucket"},
+		{"https://my-bucket.s3.cn-north-1.amazonaws.com.cn", "my-bucket"},
+		{"arn:aws:s3:::my-bucket", "arn:aws:s3:::my-bucket"},
+		{"arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", "arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap"},
+		{"https://example.com/my-bucket", "https://example.com/my-bucket"},
+		{"   ", ""},
+	}
+
+	for _, test := range tests {
+		if got := cleanBucketName(test.input); got != test.want {
+			t.Errorf("cleanBucketName(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/url"
+	"os"
+	"time"
+)
+
+// effectiveConfig is the resolved configuration of a run, written at start
+// so that scans can be reproduced and compared
+type effectiveConfig struct {
+	StartedAt   string            `json:"started_at"`
+	Version     string            `json:"version"`
+	Flags       map[string]string `json:"flags"`
+	Concurrency int               `json:"concurrency"`
+	AWSRegion   string            `json:"aws_region"`
+	AWSProfile  string            `json:"aws_profile"`
+}
+
+// secretFlags hold values that mustn't be written to a dump, which
+// -report-upload may copy elsewhere
+var secretFlags = map[string]bool{
+	"external-id": true,
+}
+
+// urlFlags hold URLs that may carry a password in their userinfo
+var urlFlags = map[string]bool{
+	"proxy":    true,
+	"endpoint": true,
+}
+
+// redactFlag masks a secret flag's value, and the password in a URL flag,
+// the way url.URL.Redacted does
+func redactFlag(name string, value string) string {
+	switch {
+	case value == "":
+		return value
+	case secretFlags[name]:
+		return "xxxxx"
+	case urlFlags[name]:
+		u, err := url.Parse(value)
+		if err != nil {
+			// an unparsable URL may still hold a password
+			return "xxxxx"
+		}
+		return u.Redacted()
+	}
+	return value
+}
+
+func resolveConfig(ctx context.Context) (effectiveConfig, error) {
+	resolved := effectiveConfig{
+		StartedAt:   time.Now().UTC().Format(time.RFC3339),
+		Version:     versionString(),
+		Flags:       map[string]string{},
+		Concurrency: concurrency,
+		AWSProfile:  awsProfile,
+	}
+	if resolved.AWSProfile == "" {
+		resolved.AWSProfile = os.Getenv("AWS_PROFILE")
+	}
+	if resolved.AWSProfile == "" {
+		resolved.AWSProfile = "default"
+	}
+
+	// VisitAll includes flags left at their defaults, so every setting that
+	// shaped the run is recorded
+	flag.VisitAll(func(f *flag.Flag) {
+		resolved.Flags[f.Name] = redactFlag(f.Name, f.Value.String())
+	})
+
+	cfg, err := scanner.LoadConfig(ctx)
+	if err != nil {
+		return resolved, err
+	}
+	resolved.AWSRegion = cfg.Region
+	return resolved, nil
+}
+
+// dumpConfig writes the effective configuration as JSON to path, or to
+// stderr when path is "-"
+func dumpConfig(ctx context.Context, path string) error {
+	resolved, err := resolveConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stderr
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(resolved)
+}
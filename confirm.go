@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// confirmAggressive asks on the terminal before an -a scan writes to real
+// buckets, since stdin is usually the bucket list. total is the number of
+// buckets in the input, or 0 when it isn't known.
+func confirmAggressive(total int64) (bool, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false, errors.New("-a writes to the buckets scanned and there is no terminal to confirm it on, use -yes to go ahead")
+	}
+	defer tty.Close()
+	return askConfirmation(tty, tty, total)
+}
+
+// askConfirmation says what an -a scan will write and reads a yes or no
+// from in, taking anything but yes as no
+func askConfirmation(in io.Reader, out io.Writer, total int64) (bool, error) {
+	buckets := "every bucket in the input"
+	if total > 0 {
+		buckets = fmt.Sprintf("the %d buckets in the input", total)
+	}
+	fmt.Fprintf(out, "-a will upload and delete a test object, and add and then remove a test ACL grant, on %s and their objects.\nContinue? [y/N] ", buckets)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
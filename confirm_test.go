@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAskConfirmation(t *testing.T) {
+	tests := []struct {
+		answer string
+		total  int64
+		want   bool
+		prompt string
+	}{
+		{"y\n", 12, true, "the 12 buckets in the input"},
+		{"YES\n", 0, true, "every bucket in the input"},
+		{"n\n", 0, false, "every bucket in the input"},
+		{"\n", 3, false, "the 3 buckets in the input"},
+		{"", 0, false, "every bucket in the input"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got, err := askConfirmation(strings.NewReader(tt.answer), &out, tt.total)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("answer %q: confirmed = %v, want %v", tt.answer, got, tt.want)
+		}
+		if !strings.Contains(out.String(), tt.prompt) {
+			t.Errorf("prompt = %q, want it to mention %q", out.String(), tt.prompt)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// csvHeader names the columns of -csv output, one row per finding
+var csvHeader = []string{"bucket", "region", "finding_type", "severity", "object_key", "detail"}
+
+var (
+	// csvMu keeps rows from findings reported at the same time whole
+	csvMu  sync.Mutex
+	csvOut io.Writer = os.Stdout
+)
+
+// csvLine encodes one row, quoting fields as needed
+func csvLine(row []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(row)
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// csvCell keeps a spreadsheet from running an object key or message as a
+// formula, since anyone who can write to a bucket chooses its keys
+func csvCell(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
+
+func writeCSVRow(row []string, toOutput bool) {
+	csvMu.Lock()
+	defer csvMu.Unlock()
+
+	line := csvLine(row)
+	fmt.Fprintln(csvOut, line)
+	if toOutput {
+		writeOutput(line)
+	}
+}
+
+// writeCSVHeader starts the -csv output, and the -o file unless it already
+// holds earlier results
+func writeCSVHeader() {
+	writeCSVRow(csvHeader, !outputAppended)
+}
+
+// writeCSVFinding writes a finding as a row of -csv output
+func writeCSVFinding(f warden.Finding) {
+	writeCSVRow([]string{
+		f.Bucket,
+		f.Region,
+		f.Kind,
+		f.Severity,
+		csvCell(f.Key),
+		csvCell(findingText(f, f.Message)),
+	}, true)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestCSVOutput(t *testing.T) {
+	var b bytes.Buffer
+	old := csvOut
+	csvOut = &b
+	defer func() { csvOut = old }()
+
+	writeCSVHeader()
+	writeCSVFinding(warden.Finding{Bucket: "open", Region: "eu-west-1", Kind: "open-listing", Severity: "MEDIUM", Message: "Open listing, of bucket open"})
+	writeCSVFinding(warden.Finding{Bucket: "open", Region: "eu-west-1", Kind: "object-public-read", Severity: "MEDIUM", Key: "=cmd|'/c calc'!A1", Message: "Public object"})
+
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		csvHeader,
+		{"open", "eu-west-1", "open-listing", "MEDIUM", "", "Open listing, of bucket open"},
+		{"open", "eu-west-1", "object-public-read", "MEDIUM", "'=cmd|'/c calc'!A1", "Public object"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
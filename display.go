@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// shortenKey cuts a key to at most width characters by replacing its middle
+// with "...", keeping the leading prefix and the file name readable
+func shortenKey(key string, width int) string {
+	runes := []rune(key)
+	if width <= 0 || len(runes) <= width {
+		return key
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	head := (width - 3) / 2
+	tail := width - 3 - head
+	return string(runes[:head]) + "..." + string(runes[len(runes)-tail:])
+}
+
+// displayMessage is a finding's message for the terminal, with the object
+// key shortened to -key-display-width. Machine-readable outputs keep the
+// full message.
+func displayMessage(f warden.Finding) string {
+	if keyDisplayWidth <= 0 || f.Key == "" {
+		return f.Message
+	}
+	key := warden.Printable(f.Key)
+	return strings.Replace(f.Message, key, shortenKey(key, keyDisplayWidth), 1)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestShortenKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		width int
+		want  string
+	}{
+		{"short.txt", 20, "short.txt"},
+		{"exactly-ten", 11, "exactly-ten"},
+		{"logs/2024/01/01/very-long-name.gz", 15, "logs/2...ame.gz"},
+		{"anything", 0, "anything"},
+		{"abcdef", 2, "ab"},
+		{"ééééééééé", 5, "é...é"},
+	}
+
+	for _, tt := range tests {
+		got := shortenKey(tt.key, tt.width)
+		if got != tt.want {
+			t.Errorf("shortenKey(%q, %d) = %q, want %q", tt.key, tt.width, got, tt.want)
+		}
+		if tt.width > 0 && len([]rune(got)) > tt.width {
+			t.Errorf("shortenKey(%q, %d) is %d characters", tt.key, tt.width, len([]rune(got)))
+		}
+	}
+}
+
+func TestDisplayMessage(t *testing.T) {
+	old := keyDisplayWidth
+	defer func() { keyDisplayWidth = old }()
+	keyDisplayWidth = 9
+
+	f := warden.Finding{Bucket: "b", Key: "dir/long-key.txt", Message: "Readable object found: b/dir/long-key.txt"}
+	if got, want := displayMessage(f), "Readable object found: b/dir...txt"; got != want {
+		t.Errorf("displayMessage() = %q, want %q", got, want)
+	}
+
+	bucketOnly := warden.Finding{Bucket: "b", Message: "Possible open directory listing in b"}
+	if got := displayMessage(bucketOnly); got != bucketOnly.Message {
+		t.Errorf("displayMessage() changed a bucket finding: %q", got)
+	}
+}
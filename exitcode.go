@@ -0,0 +1,59 @@
+package main
+
+import "github.com/cybercdh/s3-warden/warden"
+
+// Exit codes for the findings. 1 is left for errors that end the run and 2
+// is what the flag package exits with on a bad flag, so the findings use
+// the codes after them.
+const (
+	exitClean       = 0
+	exitPublicRead  = 3
+	exitPublicWrite = 4
+)
+
+// exposure ranks how open a finding shows a bucket or object to be
+type exposure int
+
+const (
+	exposureNone exposure = iota
+	exposureRead
+	exposureWrite
+)
+
+// findingExposure ranks a finding. Anything letting the public, or any AWS
+// account, change data or permissions is a write, and anything letting them
+// see it a read.
+func findingExposure(f warden.Finding) exposure {
+	switch {
+	case publicWrite(f.Kind), f.Kind == warden.KindWritableACP, f.Kind == warden.KindWritableObjectACP, f.Kind == warden.KindObjectPublicWrite:
+		return exposureWrite
+	case (f.Kind == warden.KindObjectACLOutlier || authenticatedUsers(f.Kind)) && f.Permission != "READ":
+		return exposureWrite
+	case publicRead(f.Kind), f.Kind == warden.KindOpenListing, f.Kind == warden.KindObjectPublicRead, f.Kind == warden.KindObjectReadable, f.Kind == warden.KindObjectACLOutlier, authenticatedUsers(f.Kind):
+		return exposureRead
+	}
+	return exposureNone
+}
+
+// authenticatedUsers reports whether a kind is a grant to any AWS account,
+// on a bucket or an object
+func authenticatedUsers(kind string) bool {
+	return kind == warden.KindAuthenticatedUsers || kind == warden.KindObjectAuthenticatedUsers
+}
+
+// exitCode picks the exit code for the worst exposure found, ignoring
+// reads with -fail-on write and everything with -fail-on never
+func (s *runSummary) exitCode(failOn string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case failOn == "never":
+		return exitClean
+	case s.exposure == exposureWrite:
+		return exitPublicWrite
+	case s.exposure == exposureRead && failOn == "read":
+		return exitPublicRead
+	}
+	return exitClean
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings []warden.Finding
+		failOn   string
+		want     int
+	}{
+		{"no findings", nil, "read", exitClean},
+		{"informational only", []warden.Finding{{Bucket: "a", Kind: "scan-incomplete"}}, "read", exitClean},
+		{"public read", []warden.Finding{{Bucket: "a", Kind: "public-read"}}, "read", exitPublicRead},
+		{"open listing", []warden.Finding{{Bucket: "a", Kind: "open-listing"}}, "read", exitPublicRead},
+		{"read ignored", []warden.Finding{{Bucket: "a", Kind: "policy-public"}}, "write", exitClean},
+		{"public write", []warden.Finding{{Bucket: "a", Kind: "public-read"}, {Bucket: "b", Kind: "public-write"}}, "read", exitPublicWrite},
+		{"writable acp", []warden.Finding{{Bucket: "a", Kind: "writable-acp"}}, "write", exitPublicWrite},
+		{"writable outlier", []warden.Finding{{Bucket: "a", Kind: "object-acl-outlier", Permission: "FULL_CONTROL"}}, "write", exitPublicWrite},
+		{"readable outlier", []warden.Finding{{Bucket: "a", Kind: "object-acl-outlier", Permission: "READ"}}, "write", exitClean},
+		{"authenticated read", []warden.Finding{{Bucket: "a", Kind: "authenticated-users", Permission: "READ"}}, "read", exitPublicRead},
+		{"authenticated write", []warden.Finding{{Bucket: "a", Kind: "object-authenticated-users", Permission: "WRITE"}}, "write", exitPublicWrite},
+		{"never", []warden.Finding{{Bucket: "a", Kind: "public-write"}}, "never", exitClean},
+	}
+
+	for _, tt := range tests {
+		s := newRunSummary()
+		for _, f := range tt.findings {
+			s.record(f)
+		}
+		if got := s.exitCode(tt.failOn); got != tt.want {
+			t.Errorf("%s: exitCode(%q) = %d, want %d", tt.name, tt.failOn, got, tt.want)
+		}
+	}
+}
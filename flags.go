@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// stringList is a flag that can be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseChecks splits a -checks list, returning the known checks and the
+// names that aren't checks
+func parseChecks(value string) (checks []string, unknown []string) {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "":
+		case name == "all" || warden.IsCheck(name):
+			checks = append(checks, name)
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+	return checks, unknown
+}
+
+// parseExtensions splits an -interesting-ext list into a set of lower case
+// extensions without their leading dot
+func parseExtensions(value string) map[string]bool {
+	exts := map[string]bool{}
+	for _, ext := range strings.Split(value, ",") {
+		ext = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+		if ext != "" {
+			exts[ext] = true
+		}
+	}
+	return exts
+}
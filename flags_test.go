@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringList(t *testing.T) {
+	var list stringList
+	list.Set("a=1")
+	list.Set("b")
+	if got := list.String(); got != "a=1,b" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParseChecks(t *testing.T) {
+	checks, unknown := parseChecks(" ACL, policy,,versoning ")
+	if want := []string{"acl", "policy"}; !reflect.DeepEqual(checks, want) {
+		t.Errorf("checks = %v, want %v", checks, want)
+	}
+	if want := []string{"versoning"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("unknown = %v, want %v", unknown, want)
+	}
+
+	if checks, unknown := parseChecks(""); checks != nil || unknown != nil {
+		t.Errorf("parseChecks(\"\") = %v, %v, want nothing", checks, unknown)
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	got := parseExtensions(" .ENV, pem,,sql ")
+	want := map[string]bool{"env": true, "pem": true, "sql": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseExtensions() = %v, want %v", got, want)
+	}
+}
+
+func TestInterestingKey(t *testing.T) {
+	old := interestingExts
+	defer func() { interestingExts = old }()
+	interestingExts = parseExtensions(defaultInterestingExts)
+
+	for key, want := range map[string]bool{
+		"app/.env":           true,
+		"keys/server.PEM":    true,
+		"backups/db.sql":     true,
+		"images/logo.png":    false,
+		"Makefile":           false,
+		"archive.sql.tar.gz": false,
+	} {
+		if got := interestingKey(key); got != want {
+			t.Errorf("interestingKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// readTargets decodes bucket names from r in the given input format and
+// sends them to out. The lines format is one bucket name per line, and the
+// json format is an array of {"bucket": ..., "region": ...} objects which
+// is decoded as a stream so large files aren't held in memory. Names are
+// cleaned up with cleanBucketName and empty ones skipped.
+func readTargets(r io.Reader, format string, out chan<- warden.Target) error {
+	switch format {
+	case "lines":
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if bucket := cleanBucketName(scanner.Text()); bucket != "" {
+				out <- warden.Target{Bucket: bucket}
+			}
+		}
+		return scanner.Err()
+	case "json":
+		return readJSONTargets(r, out)
+	default:
+		return fmt.Errorf("unknown input format %q", format)
+	}
+}
+
+func readJSONTargets(r io.Reader, out chan<- warden.Target) error {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected a JSON array of buckets")
+	}
+
+	for decoder.More() {
+		var entry struct {
+			Bucket string `json:"bucket"`
+			Region string `json:"region"`
+		}
+		if err := decoder.Decode(&entry); err != nil {
+			return err
+		}
+		bucket := cleanBucketName(entry.Bucket)
+		if bucket == "" {
+			continue
+		}
+		out <- warden.Target{Bucket: bucket, Region: entry.Region}
+	}
+
+	_, err = decoder.Token()
+	return err
+}
+
+// readShuffledTargets reads every target before sending any of them, in a
+// random order. Lists are often grouped by region or name, and shuffling
+// spreads the load rather than working through one group at a time.
+func readShuffledTargets(r io.Reader, format string, out chan<- warden.Target, rng *rand.Rand) error {
+	all := make(chan warden.Target)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- readTargets(r, format, all)
+		close(all)
+	}()
+
+	var buffered []warden.Target
+	for t := range all {
+		buffered = append(buffered, t)
+	}
+	rng.Shuffle(len(buffered), func(i, j int) {
+		buffered[i], buffered[j] = buffered[j], buffered[i]
+	})
+
+	for _, t := range buffered {
+		out <- t
+	}
+	return <-errc
+}
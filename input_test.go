@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func collectTargets(t *testing.T, input string, format string) ([]warden.Target, error) {
+	t.Helper()
+	out := make(chan warden.Target)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- readTargets(strings.NewReader(input), format, out)
+		close(out)
+	}()
+
+	var targets []warden.Target
+	for tg := range out {
+		targets = append(targets, tg)
+	}
+	return targets, <-errc
+}
+
+func TestReadTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  string
+		want    []warden.Target
+		wantErr bool
+	}{
+		{
+			name:   "lines",
+			input:  "one\ntwo\n",
+			format: "lines",
+			want:   []warden.Target{{Bucket: "one"}, {Bucket: "two"}},
+		},
+		{
+			name:   "blank lines and pasted names",
+			input:  "one\n\n   \n  two  \ns3://three/\n",
+			format: "lines",
+			want:   []warden.Target{{Bucket: "one"}, {Bucket: "two"}, {Bucket: "three"}},
+		},
+		{
+			name:   "json with hints",
+			input:  `[{"bucket":"one","region":"eu-west-1"},{"bucket":"two"},{"region":"us-east-1"}]`,
+			format: "json",
+			want:   []warden.Target{{Bucket: "one", Region: "eu-west-1"}, {Bucket: "two"}},
+		},
+		{
+			name:    "json object instead of array",
+			input:   `{"bucket":"one"}`,
+			format:  "json",
+			wantErr: true,
+		},
+		{
+			name:    "truncated json",
+			input:   `[{"bucket":"one"},`,
+			format:  "json",
+			want:    []warden.Target{{Bucket: "one"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			input:   "one\n",
+			format:  "csv",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectTargets(t, tt.input, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadShuffledTargets(t *testing.T) {
+	input := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	out := make(chan warden.Target)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- readShuffledTargets(strings.NewReader(input), "lines", out, rand.New(rand.NewSource(1)))
+		close(out)
+	}()
+
+	var got []string
+	for tg := range out {
+		got = append(got, tg.Bucket)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	inOrder := strings.Fields(input)
+	if reflect.DeepEqual(got, inOrder) {
+		t.Errorf("targets came out in input order: %v", got)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, inOrder) {
+		t.Errorf("shuffled targets = %v, want a permutation of %v", got, inOrder)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// exitInterrupted is the shell's code for a run ended by SIGINT, used when
+// an interrupted scan found nothing to fail on
+const exitInterrupted = 130
+
+// interrupted is set once the first SIGINT or SIGTERM arrives
+var interrupted atomic.Bool
+
+// handleInterrupts stops the scan gracefully on the first SIGINT or
+// SIGTERM, closing the returned channel so no more buckets are started
+// while those in progress, and their test upload cleanup, finish. A second
+// signal calls abort to cancel the buckets in progress too. The returned
+// quit abandons the scan the same way at once, for the TUI's quit key.
+func handleInterrupts(abort context.CancelFunc) (<-chan struct{}, func()) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopFeeding := func() {
+		interrupted.Store(true)
+		stopOnce.Do(func() { close(stop) })
+	}
+	quit := func() {
+		stopFeeding()
+		abort()
+	}
+
+	go func() {
+		<-signals
+		logf(levelWarn, "Interrupted, finishing the buckets in progress. Interrupt again to abandon them")
+		stopFeeding()
+
+		<-signals
+		logf(levelWarn, "Interrupted again, abandoning the buckets in progress")
+		abort()
+
+		// a third signal gets the default behaviour back, in case the
+		// abandoned buckets are slow to notice
+		signal.Stop(signals)
+	}()
+	return stop, quit
+}
+
+// feedTargets passes the targets read on to the scanner until stop is
+// closed, then closes targets so the scan winds down
+func feedTargets(read <-chan warden.Target, targets chan<- warden.Target, stop <-chan struct{}) {
+	defer close(targets)
+	for t := range read {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		select {
+		case targets <- t:
+		case <-stop:
+			return
+		}
+	}
+}
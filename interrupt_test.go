@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestFeedTargetsStops(t *testing.T) {
+	read := make(chan warden.Target)
+	targets := make(chan warden.Target)
+	stop := make(chan struct{})
+	go feedTargets(read, targets, stop)
+
+	read <- warden.Target{Bucket: "first"}
+	if got := <-targets; got.Bucket != "first" {
+		t.Fatalf("got %q, want first", got.Bucket)
+	}
+
+	close(stop)
+	// the read target may already have been taken before stop is seen
+	go func() { read <- warden.Target{Bucket: "second"} }()
+	if got, ok := <-targets; ok {
+		t.Errorf("got %q after stopping, want targets closed", got.Bucket)
+	}
+}
+
+func TestFeedTargetsPassesAll(t *testing.T) {
+	read := make(chan warden.Target, 3)
+	for _, bucket := range []string{"a", "b", "c"} {
+		read <- warden.Target{Bucket: bucket}
+	}
+	close(read)
+	targets := make(chan warden.Target)
+	go feedTargets(read, targets, make(chan struct{}))
+
+	var got []string
+	for target := range targets {
+		got = append(got, target.Bucket)
+	}
+	if len(got) != 3 {
+		t.Errorf("got %v, want all three targets", got)
+	}
+}
+
+func TestHandleInterruptsQuit(t *testing.T) {
+	defer interrupted.Store(false)
+	ctx, abort := context.WithCancel(context.Background())
+	stop, quit := handleInterrupts(abort)
+
+	quit()
+	// a second quit mustn't close stop again
+	quit()
+
+	select {
+	case <-stop:
+	default:
+		t.Error("stop is still open after quit")
+	}
+	if ctx.Err() == nil {
+		t.Error("the scan context wasn't cancelled")
+	}
+	if !interrupted.Load() {
+		t.Error("the scan isn't marked interrupted, so its exit code wouldn't be 130")
+	}
+}
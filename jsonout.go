@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// bucketResult is one line of -json output, summing up a bucket's findings
+type bucketResult struct {
+	Bucket      string `json:"bucket"`
+	Region      string `json:"region,omitempty"`
+	Status      string `json:"status,omitempty"`
+	PublicRead  bool   `json:"public_read"`
+	PublicWrite bool   `json:"public_write"`
+	OpenListing bool   `json:"open_listing"`
+
+	// Findings are about the bucket itself and Objects about its objects
+	Findings []warden.Finding `json:"findings"`
+	Objects  []warden.Finding `json:"objects"`
+}
+
+var (
+	// jsonMu guards the results and the writer, since findings arrive while
+	// other buckets finish
+	jsonMu      sync.Mutex
+	jsonResults           = map[string]*bucketResult{}
+	jsonOut     io.Writer = os.Stdout
+
+	// bucketsDone passes finished buckets from the scan to the main loop
+	bucketsDone = make(chan string)
+)
+
+func jsonResult(bucket string) *bucketResult {
+	result, ok := jsonResults[bucket]
+	if !ok {
+		result = &bucketResult{Bucket: bucket, Findings: []warden.Finding{}, Objects: []warden.Finding{}}
+		jsonResults[bucket] = result
+	}
+	return result
+}
+
+// recordJSON adds a finding to its bucket's result
+func recordJSON(f warden.Finding) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+
+	result := jsonResult(f.Bucket)
+	if result.Region == "" {
+		result.Region = f.Region
+	}
+	switch {
+	case publicRead(f.Kind):
+		result.PublicRead = true
+	case publicWrite(f.Kind):
+		result.PublicWrite = true
+	case f.Kind == warden.KindOpenListing:
+		result.OpenListing = true
+	}
+	if f.Key != "" {
+		result.Objects = append(result.Objects, f)
+	} else {
+		result.Findings = append(result.Findings, f)
+	}
+}
+
+// recordJSONStatus notes whether a finished bucket turned out to exist,
+// since it may have no findings to show it
+func recordJSONStatus(bucket string, status warden.BucketStatus) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+
+	jsonResult(bucket).Status = string(status)
+}
+
+// writeJSONResult writes a finished bucket's result as one line of JSON,
+// including buckets with no findings so that every bucket scanned appears
+func writeJSONResult(bucket string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+
+	writeJSONLine(jsonResult(bucket))
+	delete(jsonResults, bucket)
+}
+
+// holdJSONResult keeps a result for a finished bucket, to be written by
+// writeAllJSON even if the bucket has no findings
+func holdJSONResult(bucket string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+
+	jsonResult(bucket)
+}
+
+// writeAllJSON writes the results not yet written, in bucket order
+func writeAllJSON() {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+
+	buckets := make([]string, 0, len(jsonResults))
+	for bucket := range jsonResults {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+	for _, bucket := range buckets {
+		writeJSONLine(jsonResults[bucket])
+		delete(jsonResults, bucket)
+	}
+}
+
+func writeJSONLine(result *bucketResult) {
+	line, err := json.Marshal(result)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to encode the result for %s, %v\n", warden.Printable(result.Bucket), err)
+		return
+	}
+	fmt.Fprintf(jsonOut, "%s\n", line)
+	writeOutput(string(line))
+}
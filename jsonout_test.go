@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestJSONResults(t *testing.T) {
+	var b bytes.Buffer
+	old := jsonOut
+	jsonOut = &b
+	defer func() { jsonOut = old }()
+
+	recordJSON(warden.Finding{Bucket: "open", Region: "eu-west-1", Kind: "open-listing"})
+	recordJSON(warden.Finding{Bucket: "open", Region: "eu-west-1", Kind: "upload-allowed"})
+	recordJSON(warden.Finding{Bucket: "open", Key: "a.txt", Kind: "object-public-read"})
+	recordJSONStatus("open", warden.BucketAccessible)
+	recordJSONStatus("clean", warden.BucketPrivate)
+	writeJSONResult("open")
+	writeJSONResult("clean")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrote %d lines, want one per bucket: %q", len(lines), b.String())
+	}
+
+	var open bucketResult
+	if err := json.Unmarshal([]byte(lines[0]), &open); err != nil {
+		t.Fatal(err)
+	}
+	if !open.OpenListing || !open.PublicWrite || open.PublicRead || open.Region != "eu-west-1" || open.Status != "accessible" {
+		t.Errorf("open result = %+v, want an open, publicly writable bucket in eu-west-1", open)
+	}
+	if len(open.Findings) != 2 || len(open.Objects) != 1 {
+		t.Errorf("open result has %d bucket and %d object findings, want 2 and 1", len(open.Findings), len(open.Objects))
+	}
+
+	if want := `{"bucket":"clean","status":"private","public_read":false,"public_write":false,"open_listing":false,"findings":[],"objects":[]}`; lines[1] != want {
+		t.Errorf("clean result = %s, want %s", lines[1], want)
+	}
+}
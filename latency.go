@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// printLatencyStats writes p50/p95 latencies per operation to stderr
+func printLatencyStats(latencies map[string][]time.Duration) {
+	operations := make([]string, 0, len(latencies))
+	for operation := range latencies {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	for _, operation := range operations {
+		samples := latencies[operation]
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		fmt.Fprintf(os.Stderr, "%-20s calls=%d p50=%s p95=%s\n", operation, len(samples),
+			percentile(samples, 0.50).Round(time.Millisecond), percentile(samples, 0.95).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	twenty := make([]time.Duration, 20)
+	for i := range twenty {
+		twenty[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		p       float64
+		want    time.Duration
+	}{
+		{"single sample p50", []time.Duration{7 * time.Millisecond}, 0.50, 7 * time.Millisecond},
+		{"single sample p95", []time.Duration{7 * time.Millisecond}, 0.95, 7 * time.Millisecond},
+		{"p50 of 20", twenty, 0.50, 10 * time.Millisecond},
+		{"p95 of 20", twenty, 0.95, 19 * time.Millisecond},
+		{"p0 clamps to first", twenty, 0, 1 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.samples, tt.p); got != tt.want {
+				t.Errorf("percentile(%v) = %s, want %s", tt.p, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// every worker can hold a connection for the region lookup and a few to
+// S3, and the process needs some descriptors of its own
+const (
+	fdsPerWorker  = 4
+	fdsReserved   = 32
+	workersPerCPU = 16
+)
+
+// concurrencyCeiling is the most workers that fit under a descriptor
+// limit before the scan fails with "too many open files"
+func concurrencyCeiling(fdLimit uint64) int {
+	if fdLimit <= fdsReserved+fdsPerWorker {
+		return 1
+	}
+	return int((fdLimit - fdsReserved) / fdsPerWorker)
+}
+
+// recommendedConcurrency scales with the CPU count, capped by the ceiling
+func recommendedConcurrency(cpus int, ceiling int) int {
+	recommended := cpus * workersPerCPU
+	if ceiling > 0 && recommended > ceiling {
+		recommended = ceiling
+	}
+	return recommended
+}
+
+// maxUsefulConcurrency is roughly where more workers only bring more
+// throttling from S3
+const maxUsefulConcurrency = 500
+
+// validateConcurrency rejects a -c that would leave no workers, and returns
+// a warning for one far past what S3 will serve
+func validateConcurrency(c int) (string, error) {
+	if c < 1 {
+		return "", fmt.Errorf("invalid -c %d, at least one worker is needed", c)
+	}
+	if c > maxUsefulConcurrency {
+		return fmt.Sprintf("Warning: -c %d is above %d, where S3 mostly throttles the extra workers", c, maxUsefulConcurrency), nil
+	}
+	return "", nil
+}
+
+// applyConcurrencyLimits picks a concurrency when -auto-concurrency is set,
+// and otherwise exits when -c is below 1 and warns when it exceeds what the
+// descriptor limit allows
+func applyConcurrencyLimits() {
+	ceiling := 0
+	if fdLimit, ok := openFileLimit(); ok {
+		ceiling = concurrencyCeiling(fdLimit)
+	}
+
+	if autoConcurrency {
+		concurrency = recommendedConcurrency(runtime.NumCPU(), ceiling)
+		logf(levelInfo, "Using a concurrency of %d", concurrency)
+		return
+	}
+
+	warning, err := validateConcurrency(concurrency)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if warning != "" {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	if ceiling > 0 && concurrency > ceiling {
+		fmt.Fprintf(os.Stderr, "Warning: -c %d may exhaust the open file limit, consider -c %d or raising ulimit -n\n", concurrency, ceiling)
+	}
+}
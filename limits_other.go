@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// openFileLimit is unknown on platforms without rlimits
+func openFileLimit() (uint64, bool) {
+	return 0, false
+}
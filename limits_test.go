@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestConcurrencyCeiling(t *testing.T) {
+	tests := []struct {
+		limit uint64
+		want  int
+	}{
+		{0, 1},
+		{36, 1},
+		{256, 56},
+		{1024, 248},
+		{65536, 16376},
+	}
+
+	for _, tt := range tests {
+		if got := concurrencyCeiling(tt.limit); got != tt.want {
+			t.Errorf("concurrencyCeiling(%d) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendedConcurrency(t *testing.T) {
+	tests := []struct {
+		cpus    int
+		ceiling int
+		want    int
+	}{
+		{4, 0, 64},
+		{4, 1000, 64},
+		{4, 56, 56},
+		{1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		if got := recommendedConcurrency(tt.cpus, tt.ceiling); got != tt.want {
+			t.Errorf("recommendedConcurrency(%d, %d) = %d, want %d", tt.cpus, tt.ceiling, got, tt.want)
+		}
+	}
+}
+
+func TestValidateConcurrency(t *testing.T) {
+	tests := []struct {
+		c       int
+		warn    bool
+		wantErr bool
+	}{
+		{-1, false, true},
+		{0, false, true},
+		{1, false, false},
+		{500, false, false},
+		{5000, true, false},
+	}
+
+	for _, tt := range tests {
+		warning, err := validateConcurrency(tt.c)
+		if (err != nil) != tt.wantErr || (warning != "") != tt.warn {
+			t.Errorf("validateConcurrency(%d) = %q, %v", tt.c, warning, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// logLevel orders the diagnostics, which all go to stderr so that stdout
+// carries only findings
+type logLevel int
+
+const (
+	// levelDebug is the scan's progress, shown with -v
+	levelDebug logLevel = iota
+	// levelInfo is failed API calls, which are expected for private
+	// buckets, and notes about the run
+	levelInfo
+	// levelWarn is problems worth showing by default
+	levelWarn
+	// levelError is only problems that end the run
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+func (l logLevel) String() string {
+	return logLevelNames[l]
+}
+
+// parseLogLevel returns the level for a -log-level value
+func parseLogLevel(name string) (logLevel, error) {
+	for i, levelName := range logLevelNames {
+		if levelName == name {
+			return logLevel(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, use debug, info, warn or error", name)
+}
+
+var (
+	// logMu keeps lines logged at the same time whole
+	logMu       sync.Mutex
+	logOut      io.Writer = os.Stderr
+	minLogLevel           = levelWarn
+)
+
+// logf writes a line at level, if it is at least -log-level, as logfmt
+// key=value pairs that can be filtered by level
+func logf(level logLevel, format string, a ...any) {
+	if level < minLogLevel {
+		return
+	}
+	message := fmt.Sprintf(format, a...)
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	fmt.Fprintf(logOut, "time=%s level=%s msg=%s\n", time.Now().UTC().Format(time.RFC3339), level, strconv.Quote(message))
+}
+
+// cliLogger logs the scanner's progress at debug, failed calls at info
+// unless -quiet-errors is given, and warnings at warn. Calls that failed
+// unexpectedly are counted for the summary either way; access denied and
+// other failures a private bucket normally returns are not.
+type cliLogger struct{}
+
+func (cliLogger) Debugf(format string, a ...any) {
+	logf(levelDebug, format, a...)
+}
+
+func (cliLogger) Infof(format string, a ...any) {
+	if !quietErrors {
+		logf(levelInfo, format, a...)
+	}
+}
+
+func (cliLogger) Warnf(format string, a ...any) {
+	logf(levelWarn, format, a...)
+}
+
+func (cliLogger) Errorf(format string, a ...any) {
+	summary.errors.Add(1)
+	if !quietErrors {
+		logf(levelInfo, format, a...)
+	}
+}
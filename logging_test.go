@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestLogf(t *testing.T) {
+	var b bytes.Buffer
+	oldOut, oldLevel := logOut, minLogLevel
+	logOut, minLogLevel = &b, levelInfo
+	defer func() { logOut, minLogLevel = oldOut, oldLevel }()
+
+	logf(levelDebug, "hidden %d", 1)
+	logf(levelInfo, "Failed to get ACL for bucket %s", `a"b`)
+	logf(levelWarn, "shown")
+
+	want := regexp.MustCompile(`^time=\S+ level=info msg="Failed to get ACL for bucket a\\"b"\ntime=\S+ level=warn msg="shown"\n$`)
+	if !want.MatchString(b.String()) {
+		t.Errorf("logged %q, want only the info and warn lines", b.String())
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for name, want := range map[string]logLevel{"debug": levelDebug, "info": levelInfo, "warn": levelWarn, "error": levelError} {
+		if got, err := parseLogLevel(name); err != nil || got != want {
+			t.Errorf("parseLogLevel(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := parseLogLevel("verbose"); err == nil {
+		t.Error("parseLogLevel(verbose) succeeded, want an error")
+	}
+}
+
+func TestCLILoggerCountsUnexpectedFailures(t *testing.T) {
+	var b bytes.Buffer
+	oldOut, oldLevel, oldSummary := logOut, minLogLevel, summary
+	logOut, minLogLevel, summary = &b, levelInfo, newRunSummary()
+	defer func() { logOut, minLogLevel, summary = oldOut, oldLevel, oldSummary }()
+
+	cliLogger{}.Infof("Access denied to the ACL of bucket %s, so it exists but is private", "a")
+	cliLogger{}.Errorf("Failed to get ACL for bucket %s", "b")
+
+	if got := summary.errors.Load(); got != 1 {
+		t.Errorf("counted %d errors, want only the unexpected failure", got)
+	}
+	if lines := bytes.Count(b.Bytes(), []byte("level=info")); lines != 2 {
+		t.Errorf("logged %d info lines, want both failures", lines)
+	}
+}
@@ -3,333 +3,740 @@ package main
 import (
 	"bufio"
 	"context"
-	"crypto/tls"
 	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"math/rand"
 	"os"
+	"path"
 	"strings"
-	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/cybercdh/s3-warden/warden"
 	"github.com/gookit/color"
 )
 
 var verbose bool
 var aggressive bool
 var quick bool
+var reportExisting bool
+var reportOwners bool
+var quietErrors bool
+var logLevelFlag string
 var concurrency int
+var autoConcurrency bool
+var asffFile string
+var slowThreshold time.Duration
+var latencyStats bool
+var keysFile string
+var excludeFile string
+var interestingExtList string
+var sensitiveFile string
+var regionGuess string
+var fixedRegion string
+var retryOn5xxOnly bool
+var configDump string
+var fanout int
+var objectConcurrency int
+var includeTags stringList
+var tagMatch string
+var accountRollupEnabled bool
+var tuiEnabled bool
+var probeKeys []string
+var inputFormat string
+var breakerThreshold int
+var breakerWindow time.Duration
+var breakerCooldown time.Duration
+var requestRate float64
+var printOnly string
+var shuffle bool
+var verify bool
+var socketPath string
+var probeCommonKeys bool
+var countPastCap bool
+var resolverAddr string
+var keyDisplayWidth int
+var retryBudget int64
+var reportUpload string
+var checkTimeout time.Duration
+var showVersion bool
+var noBanner bool
+var noColor bool
+var skipPrefixes stringList
+var keyPrefix string
+var showProgress bool
+var jsonOutput bool
+var csvOutput bool
+var existsOnly bool
+var noCleanup bool
+var inputFile string
+var outputPath string
+var insecure bool
+var proxyURL string
+var anonymous bool
+var awsProfile string
+var roleARN string
+var externalID string
+var bucketTimeout int
+var noSummary bool
+var maxFindings int
+var sample int
+var listingSize bool
+var countPublicRead bool
+var maxAttempts int
+var dryRun bool
+var assumeYes bool
+var endpoint string
+var pathStyle bool
+var scanner *warden.Scanner
+var failOn string
+var severityFilter string
+var reportEncryption bool
+var checksFlag string
 
 func main() {
+	os.Exit(run())
+}
+
+// run scans the buckets given on the input and returns the exit code
+func run() int {
 
-	flag.BoolVar(&verbose, "v", false, "See more info on attempts")
+	flag.IntVar(&bucketTimeout, "timeout", 30, "Give up on a bucket after this many seconds and move on to the next, 0 for no limit")
+	flag.BoolVar(&tuiEnabled, "tui", false, "Show findings in a live terminal UI, falling back to plain output when stdout isn't a terminal")
+	flag.BoolVar(&verify, "verify", false, "Repeat the public write and writable ACP checks after the scan and keep only confirmed findings")
+	flag.BoolVar(&verbose, "v", false, "See more info on attempts, with grants and colour in the findings and -log-level debug unless it is given")
+	flag.StringVar(&logLevelFlag, "log-level", "", "Log diagnostics to stderr at this level and above: debug, info for failed API calls, warn or error (default warn)")
 	flag.BoolVar(&quick, "q", false, "Quick mode just checks the bucket ACL and for a directory listing. No enumeration of objects")
 	flag.BoolVar(&aggressive, "a", false, "Be aggressive and attempt to write to the bucket/object policy")
-	flag.IntVar(&concurrency, "c", 10, "Set the concurrency level, default 10")
+	flag.IntVar(&concurrency, "c", 10, "Set the concurrency level, from 1 up to about 500 before S3 throttles most of the extra workers")
+	flag.BoolVar(&autoConcurrency, "auto-concurrency", false, "Pick the concurrency from the CPU count and open file limit instead of -c")
+	flag.StringVar(&awsProfile, "profile", "", "Use this named profile from ~/.aws/config instead of AWS_PROFILE or the default")
+	flag.StringVar(&roleARN, "role-arn", "", "Assume this IAM role with the configured credentials and scan with its credentials, e.g. for a member account")
+	flag.StringVar(&externalID, "external-id", "", "The external ID the -role-arn trust policy requires")
+	flag.BoolVar(&anonymous, "anonymous", false, "Send unsigned requests to see buckets as an outsider would, even when credentials are configured")
+	flag.BoolVar(&accountRollupEnabled, "account-rollup", false, "Print findings rolled up by the owner each bucket's ACL discloses when the scan finishes")
+	flag.StringVar(&asffFile, "asff", "", "Write findings in AWS Security Finding Format to numbered files of up to 100, e.g. findings-1.json")
+	flag.IntVar(&breakerThreshold, "breaker-threshold", 20, "Pause the scan after this many throttled requests in a row, 0 to disable")
+	flag.DurationVar(&breakerWindow, "breaker-window", 30*time.Second, "Only count throttled requests in a row that fall within this window")
+	flag.DurationVar(&breakerCooldown, "breaker-cooldown", 30*time.Second, "How long to pause when throttled before resuming with half the workers, which are doubled again once requests succeed")
+	flag.Float64Var(&requestRate, "rate", 0, "Make at most this many S3 requests per second across all workers, 0 for no limit")
+	flag.BoolVar(&assumeYes, "yes", false, "Start an -a scan without asking for confirmation first, for automation")
+	flag.BoolVar(&dryRun, "dry-run", false, "With -a, log the uploads and ACL writes that would be made without making them, with their parameters when verbose")
+	flag.BoolVar(&countPublicRead, "count-public-read", false, "Count publicly readable objects toward -max-findings, not just outliers and publicly writable ones")
+	flag.BoolVar(&countPastCap, "count-past-cap", false, "Keep checking objects after -max-findings issues in a bucket, reporting only the total beyond that")
+	flag.DurationVar(&checkTimeout, "check-timeout", 0, "Give up on any single S3 call after this long, so the other checks on the bucket still run, e.g. 10s")
+	flag.StringVar(&configDump, "config-dump", "", "Write the effective configuration as JSON to this file, or - for stderr")
+	flag.IntVar(&fanout, "fanout", 1, "List this many top-level prefixes of a bucket in parallel when enumerating")
+	flag.IntVar(&objectConcurrency, "object-concurrency", 1, "Check this many object ACLs of a bucket at once when enumerating")
+	flag.StringVar(&endpoint, "endpoint", "", "Scan an S3-compatible store such as MinIO at this URL, using the configured region instead of looking it up")
+	flag.StringVar(&regionGuess, "first-region-guess", "", "Assume buckets are in this region and only look up the region when S3 says it is wrong")
+	flag.StringVar(&fixedRegion, "region", "", "Use this region for every bucket and never look regions up, retrying in the region S3 names when it is wrong")
+	flag.StringVar(&inputFile, "i", "", "Read bucket names from this file instead of stdin")
+	flag.StringVar(&inputFormat, "input-format", "lines", "Read stdin as lines of bucket names, or json for an array of {\"bucket\", \"region\"} objects")
+	flag.Var(&includeTags, "include-tag", "Only scan buckets tagged key=value, or just key for any value. Can be repeated")
+	flag.StringVar(&tagMatch, "tag-match", "all", "Whether a bucket must match all or any of the -include-tag filters")
+	flag.IntVar(&keyDisplayWidth, "key-display-width", 0, "Shorten object keys longer than this in terminal output, keeping the full key in ASFF and socket output")
+	flag.BoolVar(&insecure, "insecure", false, "Skip TLS certificate verification, e.g. when scanning through an intercepting proxy")
+	flag.StringVar(&proxyURL, "proxy", "", "Send the region lookup and S3 requests through this http://, https:// or socks5:// proxy")
+	flag.StringVar(&interestingExtList, "interesting-ext", defaultInterestingExts, "Highlight public objects with these comma-separated extensions in verbose output")
+	flag.StringVar(&sensitiveFile, "sensitive-file", "", "Flag public objects whose keys match the regular expressions in this file, instead of the built-in list of names such as backup and id_rsa")
+	flag.StringVar(&excludeFile, "exclude", "", "Never scan the buckets listed in this file, by name or glob pattern such as prod-*")
+	flag.StringVar(&keysFile, "keys", "", "Check only the object keys listed in this file instead of enumerating each bucket")
+	flag.BoolVar(&probeCommonKeys, "probe-common-keys", false, "On buckets that deny listing, check a built-in list of common sensitive keys such as .env and backup.zip")
+	flag.BoolVar(&existsOnly, "exists", false, "Only check whether each bucket exists, printing exists and the name for each one that does, and skip every other check")
+	flag.BoolVar(&csvOutput, "csv", false, "Write one CSV row per finding to stdout, after a header row, instead of the plain findings")
+	flag.BoolVar(&jsonOutput, "json", false, "Write one line of JSON per bucket to stdout instead of the plain findings")
+	flag.IntVar(&maxFindings, "max-findings", 5, "Move on from a bucket after this many objects with public access issues, 0 to check every object")
+	flag.BoolVar(&listingSize, "listing-size", false, "Page through each listable bucket to report how many objects and bytes it exposes")
+	flag.IntVar(&sample, "sample", 0, "Move on from a bucket after checking this many objects, whatever was found, 0 for no limit")
+	flag.IntVar(&maxAttempts, "max-attempts", 5, "Try each request up to this many times, backing off and retrying when S3 throttles")
+	flag.BoolVar(&noSummary, "no-summary", false, "Don't print the run stats and finding totals to stderr when the scan finishes")
+	flag.BoolVar(&noCleanup, "no-cleanup", false, "Leave the test object uploaded by -a in the bucket instead of deleting it")
+	flag.BoolVar(&latencyStats, "latency", false, "Print p50/p95 latency per operation when the scan finishes")
+	flag.StringVar(&socketPath, "socket", "", "Also send findings as NDJSON to the Unix domain socket listening at this path")
+	flag.BoolVar(&showProgress, "progress", false, "Print the number of buckets scanned to stderr every few seconds, out of the total when reading from -i")
+	flag.StringVar(&keyPrefix, "prefix", "", "Only enumerate objects whose keys start with this prefix, e.g. backups/. Bucket-level checks still run")
+	flag.Var(&skipPrefixes, "skip-prefix", "Don't enumerate objects under this key prefix, e.g. uploads/. Can be repeated")
+	flag.BoolVar(&shuffle, "shuffle", false, "Scan buckets in a random order, reading the whole list before starting")
+	flag.DurationVar(&slowThreshold, "slow-threshold", 0, "Warn about any call that takes longer than this, e.g. 2s")
+	flag.StringVar(&outputPath, "o", "", "Also write results to this file, appending if it exists. Holds the -json lines or -csv rows when either is given")
+	flag.BoolVar(&pathStyle, "path-style", false, "Address buckets as endpoint/bucket instead of bucket.endpoint, as many S3-compatible stores need")
+	flag.StringVar(&printOnly, "print-only", "", "Print only the names of buckets with this finding type, e.g. public-write, one per line")
+	flag.StringVar(&resolverAddr, "resolver", "", "Resolve S3 hostnames with the DNS server at this ip or ip:port instead of the system resolver")
+	flag.Int64Var(&retryBudget, "retry-budget", 0, "Stop retrying failed requests once this many retries have been made across the scan, 0 for no limit")
+	flag.BoolVar(&retryOn5xxOnly, "retry-on-5xx-only", false, "Never retry 403 and 404 responses, so access denied and missing buckets fail immediately; 5xx, throttling and connection errors are still retried")
+	flag.BoolVar(&quietErrors, "quiet-errors", false, "Hide failed API calls from the logs; unexpected failures are still counted in the summary")
+	flag.StringVar(&reportUpload, "report-upload", "", "Upload the report files written by -asff, -config-dump and -o to this s3://bucket/prefix when the scan finishes")
+	flag.BoolVar(&reportExisting, "report-existing", false, "Report every bucket that exists, even when all access is denied")
+	flag.BoolVar(&reportOwners, "owners", false, "Report the owner ID in each readable bucket ACL and the account IDs named in bucket policies, and list the unique ones when the scan finishes")
+	flag.StringVar(&checksFlag, "checks", "all", "Run only these checks on each bucket, as a comma-separated list of "+strings.Join(warden.Checks, ", ")+", or all")
+	flag.BoolVar(&reportEncryption, "encryption", false, "Report whether each bucket has default encryption, and whether it is SSE-S3 or SSE-KMS")
+	flag.StringVar(&severityFilter, "severity", "informational", "Only report findings of at least this severity: informational, low, medium or high")
+	flag.StringVar(&failOn, "fail-on", "read", "Exit non-zero when a bucket is publicly readable or writable (read), only writable (write), or never")
+	flag.BoolVar(&showVersion, "version", false, "Print the version and exit")
+	flag.BoolVar(&noBanner, "no-banner", false, "Don't print the version and main settings to stderr at startup")
+	flag.BoolVar(&noColor, "no-color", false, "Don't colour the findings with -v, as is already the case when stdout isn't a terminal or NO_COLOR is set")
 
 	flag.Parse()
 
-	ctx := context.TODO()
-	scanner := bufio.NewScanner(os.Stdin)
+	if showVersion {
+		fmt.Println("s3-warden", versionString())
+		return exitClean
+	}
 
-	// Check if stdin is connected to a terminal or a pipe/file
-	fileInfo, _ := os.Stdin.Stat()
-	if (fileInfo.Mode() & os.ModeCharDevice) != 0 {
-		fmt.Println("No input detected. Please provide a list of bucket names via stdin.")
+	// escape codes would garble a redirected or piped stdout, and the
+	// colour library only looks at NO_COLOR
+	if noColor || !stdoutIsTerminal() {
+		color.Disable()
+	}
+
+	// -v has always meant seeing the scan's progress, so it logs at debug
+	// unless a level is given
+	switch {
+	case logLevelFlag != "":
+		level, err := parseLogLevel(logLevelFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -log-level, %v\n", err)
+			os.Exit(1)
+		}
+		minLogLevel = level
+	case verbose:
+		minLogLevel = levelDebug
+	}
+
+	applyConcurrencyLimits()
+
+	if printOnly != "" && jsonOutput {
+		fmt.Fprintln(os.Stderr, "-print-only and -json can't be used together")
 		os.Exit(1)
 	}
+	if csvOutput && (jsonOutput || printOnly != "") {
+		fmt.Fprintln(os.Stderr, "-csv can't be used with -json or -print-only")
+		os.Exit(1)
+	}
+
+	if printOnly != "" {
+		if !isFindingKind(printOnly) {
+			fmt.Fprintf(os.Stderr, "Invalid -print-only %q, use one of %s\n", printOnly, strings.Join(warden.Kinds, ", "))
+			os.Exit(1)
+		}
+		// the output is meant for another tool, so nothing else goes to stdout
+		verbose = false
+		tuiEnabled = false
+	}
 
-	var wg sync.WaitGroup
-	bucketsChan := make(chan string)
+	// stdout has to stay valid NDJSON or CSV
+	if jsonOutput || csvOutput {
+		verbose = false
+		tuiEnabled = false
+	}
 
-	for i := 0; i < concurrency; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for bucketName := range bucketsChan {
-				processBucket(ctx, bucketName)
-			}
-		}()
+	// the lines of existing buckets are meant for other tools
+	if existsOnly {
+		tuiEnabled = false
 	}
 
-	// Read bucket names from stdin and send them to the channel
-	for scanner.Scan() {
-		bucketName := scanner.Text()
-		bucketsChan <- bucketName
+	// the TUI owns the screen, so only warnings are logged over it
+	tuiEnabled = tuiEnabled && stdoutIsTerminal()
+	if tuiEnabled {
+		verbose = false
+		if minLogLevel < levelWarn {
+			minLogLevel = levelWarn
+		}
 	}
-	close(bucketsChan)
 
-	wg.Wait()
+	var err error
+	if minSeverity, err = parseSeverity(severityFilter); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -severity, %v\n", err)
+		os.Exit(1)
+	}
 
-}
+	checks, unknown := parseChecks(checksFlag)
+	for _, name := range unknown {
+		fmt.Fprintf(os.Stderr, "Ignoring unknown check %q in -checks, use %s or all\n", name, strings.Join(warden.Checks, ", "))
+	}
+	if len(checks) == 0 {
+		fmt.Fprintln(os.Stderr, "No checks to run, -checks must name at least one known check")
+		os.Exit(1)
+	}
 
-func processBucket(ctx context.Context, bucketName string) {
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		log.Fatalf("Unable to load SDK config, %v", err)
+	if failOn != "read" && failOn != "write" && failOn != "never" {
+		fmt.Fprintf(os.Stderr, "Invalid -fail-on %q, use read, write or never\n", failOn)
+		os.Exit(1)
 	}
 
-	bucketRegion, err := getBucketRegion(bucketName)
-	if err != nil {
-		if verbose {
-			fmt.Printf("Unable to get the region for %s\n", bucketName)
-		}
-		return
+	if dryRun && !aggressive {
+		fmt.Fprintln(os.Stderr, "-dry-run has no effect without -a")
 	}
-	if verbose {
-		fmt.Printf("Bucket %s found in Region %s\n", bucketName, bucketRegion)
+
+	if reportUpload != "" {
+		if _, _, err := parseS3URL(reportUpload); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -report-upload, %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	cfg.Region = bucketRegion
-	client := s3.NewFromConfig(cfg)
+	if inputFormat != "lines" && inputFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid -input-format %q, use lines or json\n", inputFormat)
+		os.Exit(1)
+	}
 
-	checkBucketACL(ctx, client, bucketName)
-	checkOpenListing(ctx, client, bucketName)
+	ctx, abort := context.WithCancel(context.Background())
+	defer abort()
+	stopScan, quitScan := handleInterrupts(abort)
+	start := time.Now()
 
-	if quick {
-		return
+	input := os.Stdin
+	if inputFile != "" {
+		file, err := os.Open(inputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read the bucket list, %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		input = file
 	}
 
-	if aggressive {
-		testUpload(ctx, client, bucketName, "s3-warden-test.txt", strings.NewReader("s3-warden-test"))
-		putBucketACP(ctx, client, bucketName)
+	if outputPath != "" {
+		if err := openOutput(outputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to open the output file, %v\n", err)
+			os.Exit(1)
+		}
+		defer closeOutput()
 	}
 
-	iterateBucket(ctx, client, bucketName)
-}
+	interestingExts = parseExtensions(interestingExtList)
+
+	if keysFile != "" {
+		keys, err := readLines(keysFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read keys file, %v\n", err)
+			os.Exit(1)
+		}
+		if len(keys) == 0 {
+			fmt.Fprintf(os.Stderr, "No keys found in %s\n", keysFile)
+			os.Exit(1)
+		}
+		probeKeys = keys
+	}
 
-func getBucketRegion(bucket string) (string, error) {
-	url := fmt.Sprintf("https://%s.s3.amazonaws.com", bucket)
+	sensitive := warden.SensitivePatterns
+	if sensitiveFile != "" {
+		patterns, err := readLines(sensitiveFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read sensitive patterns file, %v\n", err)
+			os.Exit(1)
+		}
+		sensitive = patterns
+	}
 
-	// Create a custom HTTP client that ignores SSL certificate errors
-	customTransport := http.DefaultTransport.(*http.Transport).Clone()
-	customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	var excludes []string
+	if excludeFile != "" {
+		patterns, err := readLines(excludeFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read exclude file, %v\n", err)
+			os.Exit(1)
+		}
+		excludes = patterns
+	}
 
-	client := &http.Client{Transport: customTransport}
+	// -max-findings 0 checks every object, which the library asks for with
+	// a negative limit since its zero value means the default
+	if maxFindings == 0 {
+		maxFindings = -1
+	}
 
-	resp, err := client.Head(url)
+	scanner, err = warden.New(warden.Options{
+		Concurrency:       concurrency,
+		Aggressive:        aggressive,
+		DryRun:            dryRun,
+		NoCleanup:         noCleanup,
+		Quick:             quick,
+		Checks:            checks,
+		ReportExisting:    reportExisting,
+		ReportOwners:      reportOwners,
+		ExistsOnly:        existsOnly,
+		Fanout:            fanout,
+		ObjectConcurrency: objectConcurrency,
+		MaxFindings:       maxFindings,
+		Sample:            sample,
+		ListingSize:       listingSize,
+		CountPastCap:      countPastCap,
+		CountPublicRead:   countPublicRead,
+		RegionGuess:       regionGuess,
+		Region:            fixedRegion,
+		Keys:              probeKeys,
+		ProbeCommonKeys:   probeCommonKeys,
+		ReportEncryption:  reportEncryption,
+		Exclude:           excludes,
+		Sensitive:         sensitive,
+		IncludeTags:       includeTags,
+		TagMatch:          tagMatch,
+		RetryOn5xxOnly:    retryOn5xxOnly,
+		MaxAttempts:       maxAttempts,
+		RetryBudget:       retryBudget,
+		Resolver:          resolverAddr,
+		BucketTimeout:     time.Duration(bucketTimeout) * time.Second,
+		CheckTimeout:      checkTimeout,
+		Endpoint:          endpoint,
+		PathStyle:         pathStyle,
+		Insecure:          insecure,
+		Proxy:             proxyURL,
+		Anonymous:         anonymous,
+		Profile:           awsProfile,
+		RoleARN:           roleARN,
+		ExternalID:        externalID,
+		Prefix:            keyPrefix,
+		SkipPrefixes:      skipPrefixes,
+		SlowThreshold:     slowThreshold,
+		LatencyStats:      latencyStats,
+		Verify:            verify,
+		BreakerThreshold:  breakerThreshold,
+		BreakerWindow:     breakerWindow,
+		BreakerCooldown:   breakerCooldown,
+		Rate:              requestRate,
+		Logger:            cliLogger{},
+		OnBucketStatus: func(bucket string, status warden.BucketStatus) {
+			if jsonOutput {
+				recordJSONStatus(bucket, status)
+			}
+		},
+		OnBucketDone: func(bucket string) {
+			progress.done.Add(1)
+			if tuiEnabled {
+				tuiProgram.Send(tuiBucketDoneMsg{})
+			}
+			// verified findings only arrive once the scan ends, so the
+			// results are held until then
+			switch {
+			case jsonOutput && verify:
+				holdJSONResult(bucket)
+			case jsonOutput:
+				bucketsDone <- bucket
+			}
+		},
+	})
 	if err != nil {
-		return "", err
+		fmt.Fprintf(os.Stderr, "Invalid options, %v\n", err)
+		os.Exit(1)
 	}
-	defer resp.Body.Close()
 
-	region := resp.Header.Get("x-amz-bucket-region")
-	if region == "" {
-		return "", fmt.Errorf("bucket region not found in headers")
+	// the config is shared by every bucket, so a problem with it ends the
+	// run here rather than failing each bucket in turn
+	if _, err := scanner.LoadConfig(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load SDK config, %v\n", err)
+		os.Exit(1)
 	}
-	return region, nil
-}
 
-func checkOpenListing(ctx context.Context, client *s3.Client, bucket string) {
-	_, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-		Bucket:  aws.String(bucket),
-		MaxKeys: aws.Int32(1),
-	})
+	if configDump != "" {
+		if err := dumpConfig(ctx, configDump); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write the configuration, %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	if err != nil {
-		if verbose {
-			fmt.Printf("No open directory listing found in: %s\n", bucket)
+	if accountRollupEnabled {
+		account, err := callerAccount(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to prepare the account rollup, %v\n", err)
+			os.Exit(1)
 		}
-	} else {
-		if verbose {
-			color.Yellow.Printf("Possible open directory listing in %s\n", bucket)
-		} else {
-			fmt.Printf("Possible open directory listing in %s\n", bucket)
+		scanAccount = account
+	}
+
+	if asffFile != "" {
+		if err := initASFF(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to prepare ASFF output, %v\n", err)
+			os.Exit(1)
 		}
 	}
-}
 
-func checkBucketACL(ctx context.Context, client *s3.Client, bucket string) {
-	aclOutput, err := client.GetBucketAcl(ctx, &s3.GetBucketAclInput{
-		Bucket: aws.String(bucket),
-	})
-	if err != nil {
-		if verbose {
-			fmt.Printf("Failed to get ACL for bucket %s\n", bucket)
+	if socketPath != "" {
+		if err := openSocket(socketPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to connect to the findings socket, %v\n", err)
+			os.Exit(1)
 		}
-		return
+		defer closeSocket()
 	}
 
-	hasPublicRead := false
-	hasPublicWrite := false
+	// Check if stdin is connected to a terminal or a pipe/file
+	fileInfo, _ := os.Stdin.Stat()
+	stdinPiped := (fileInfo.Mode() & os.ModeCharDevice) == 0
+	if inputFile == "" && !stdinPiped {
+		fmt.Fprintln(os.Stderr, "No input detected. Please provide a list of bucket names via stdin or -i.")
+		os.Exit(1)
+	}
+	if inputFile != "" && stdinPiped {
+		logf(levelInfo, "Reading bucket names from %s, ignoring stdin", inputFile)
+	}
 
-	for _, grant := range aclOutput.Grants {
-		if grant.Grantee.Type == types.TypeGroup && *grant.Grantee.URI == "http://acs.amazonaws.com/groups/global/AllUsers" {
-			switch grant.Permission {
-			case types.PermissionRead:
-				hasPublicRead = true
-			case types.PermissionWrite, types.PermissionFullControl:
-				hasPublicWrite = true
-			}
+	// a dry run makes no writes, so has nothing to confirm
+	if aggressive && !dryRun && !assumeYes {
+		var total int64
+		if inputFile != "" {
+			total, _ = countTargets(inputFile, inputFormat)
+		}
+		confirmed, err := confirmAggressive(total)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if !confirmed {
+			fmt.Fprintln(os.Stderr, "Not confirmed, nothing was scanned")
+			os.Exit(1)
 		}
 	}
 
-	// Decide what to print based on the flags
-	if hasPublicWrite {
-		if verbose {
-			color.Red.Printf("Bucket with public write access found: %s\n", bucket)
-		} else {
-			fmt.Printf("Bucket with public write access found: %s\n", bucket)
+	// the TUI owns the screen, so it shows no banner
+	if !noBanner && !tuiEnabled {
+		printBanner(os.Stderr)
+	}
+
+	if csvOutput {
+		writeCSVHeader()
+	}
+
+	if tuiEnabled {
+		startTUI(quitScan)
+	}
+
+	// the TUI shows its own progress
+	stopProgress := func() {}
+	if showProgress && !tuiEnabled {
+		if inputFile != "" {
+			total, err := countTargets(inputFile, inputFormat)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to read the bucket list, %v\n", err)
+				os.Exit(1)
+			}
+			progress.total = total
 		}
+		stopProgress = progress.start(os.Stderr, progressInterval)
 	}
 
-	if hasPublicRead {
-		if verbose {
-			color.Yellow.Printf("Bucket with public read access found: %s\n", bucket)
+	// Read bucket names from the input and send them to the scanner, until
+	// the scan is interrupted
+	read := make(chan warden.Target)
+	go func() {
+		var err error
+		if shuffle {
+			err = readShuffledTargets(input, inputFormat, read, rand.New(rand.NewSource(time.Now().UnixNano())))
 		} else {
-			fmt.Printf("Bucket with public read access found: %s\n", bucket)
+			err = readTargets(input, inputFormat, read)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read the bucket list, %v\n", err)
+		}
+		close(read)
+	}()
+	targets := make(chan warden.Target)
+	go feedTargets(read, targets, stopScan)
+
+	// a bucket is only done once its findings have been received, so the
+	// JSON result is written from this loop rather than the scan's workers
+	findings := scanner.Scan(ctx, targets)
+	for findings != nil {
+		select {
+		case f, ok := <-findings:
+			if !ok {
+				findings = nil
+				continue
+			}
+			report(f)
+		case bucket := <-bucketsDone:
+			writeJSONResult(bucket)
 		}
 	}
 
-	if verbose && !hasPublicRead && !hasPublicWrite {
-		fmt.Printf("No public access found on bucket %s\n", bucket)
+	stopProgress()
+
+	if jsonOutput {
+		writeAllJSON()
 	}
 
-	return
-}
+	if tuiEnabled {
+		finishTUI()
+	}
 
-func testUpload(ctx context.Context, client *s3.Client, bucket string, key string, body *strings.Reader) {
-	if verbose {
-		fmt.Printf("Attempting to upload file to %s\n", bucket)
+	if !noSummary {
+		printRunStats(os.Stderr, time.Since(start), scanner.Stats())
+		summary.print(os.Stderr)
 	}
-	_, err := client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-		Body:   body,
-	})
-	if err != nil {
-		return
+
+	if latencyStats {
+		printLatencyStats(scanner.Latencies())
 	}
-	if verbose {
-		color.Green.Printf("Upload allowed in bucket %s\n", bucket)
-	} else {
-		fmt.Printf("Upload allowed in bucket %s\n", bucket)
+
+	if verbose || reportOwners {
+		printOwners(scanner.Owners())
 	}
-	return
-}
 
-func putBucketACP(ctx context.Context, client *s3.Client, bucket string) {
-	if verbose {
-		fmt.Printf("Attempting to write bucket ACP to %s\n", bucket)
+	if accountRollupEnabled {
+		printAccountRollup()
 	}
-	_, err := client.PutBucketAcl(ctx, &s3.PutBucketAclInput{
-		Bucket:    aws.String(bucket),
-		GrantRead: aws.String("uri=http://acs.amazonaws.com/groups/global/AuthenticatedUsers"),
-	})
-	if err != nil {
-		return
+
+	// reports are gathered as they are written, for -report-upload
+	var reports []string
+	if configDump != "" && configDump != "-" {
+		reports = append(reports, configDump)
 	}
-	if verbose {
-		color.Green.Printf("Writable Bucket ACP in bucket %s\n", bucket)
-	} else {
-		fmt.Printf("Writable Bucket ACP in bucket %s\n", bucket)
+	if outputPath != "" {
+		reports = append(reports, outputPath)
 	}
-	return
-}
 
-func putObjectACP(ctx context.Context, client *s3.Client, bucket string, key string) {
-	if verbose {
-		fmt.Printf("Attempting to write object ACP to %s/%s\n", bucket, key)
+	if asffFile != "" {
+		written, err := writeASFF(asffFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write ASFF findings, %v\n", err)
+			os.Exit(1)
+		}
+		reports = append(reports, written...)
 	}
-	_, err := client.PutObjectAcl(ctx, &s3.PutObjectAclInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-		ACL:    "public-read",
-	})
-	if err != nil {
-		if verbose {
-			fmt.Printf("Failed to write object ACP to %s/%s\n", bucket, key)
+
+	if reportUpload != "" {
+		// the reports of an abandoned scan are still worth keeping, so
+		// they aren't uploaded with its cancelled context
+		if err := uploadReports(context.Background(), reportUpload, reports); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to upload the reports, %v\n", err)
+			os.Exit(1)
 		}
-		return
 	}
-	if verbose {
-		color.Green.Printf("Writable Bucket Object ACP %s/%s\n", bucket, key)
-	} else {
-		fmt.Printf("Writable Bucket Object ACP %s/%s\n", bucket, key)
+
+	code := summary.exitCode(failOn)
+	if code == exitClean && interrupted.Load() {
+		return exitInterrupted
 	}
+	return code
 }
 
-func iterateBucket(ctx context.Context, client *s3.Client, bucket string) {
-	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucket),
-	})
+// findingColors highlights findings by kind in verbose output
+var findingColors = map[string]color.Color{
+	warden.KindOpenListing:              color.Yellow,
+	warden.KindPublicRead:               color.Yellow,
+	warden.KindPublicWrite:              color.Red,
+	warden.KindAuthenticatedUsers:       color.Yellow,
+	warden.KindUploadAllowed:            color.Green,
+	warden.KindWritableACP:              color.Green,
+	warden.KindWritableObjectACP:        color.Green,
+	warden.KindObjectACLOutlier:         color.Magenta,
+	warden.KindObjectPublicRead:         color.Yellow,
+	warden.KindObjectPublicWrite:        color.Red,
+	warden.KindObjectAuthenticatedUsers: color.Yellow,
+	warden.KindObjectReadable:           color.Yellow,
+	warden.KindPolicyPublic:             color.Red,
+	warden.KindPolicyPublicWrite:        color.Red,
+}
 
-	// if 5 issues are found, it's enough to stop and move on
-	issueCounter := 0
+// defaultInterestingExts are extensions of files that tend to hold
+// secrets or data dumps, which matter more when public than most objects
+const defaultInterestingExts = "env,pem,key,p12,pfx,ppk,kdbx,sql,dump,bak,tfstate"
+
+// interestingExts are the -interesting-ext extensions, and interestingColor
+// highlights the objects that have one. sensitiveColor highlights objects
+// whose keys match a -sensitive-file pattern, ahead of either.
+var (
+	interestingExts  map[string]bool
+	interestingColor = color.Cyan
+	sensitiveColor   = color.LightRed
+)
 
-	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(ctx)
-		if err != nil {
-			if verbose {
-				fmt.Printf("Failed to iterate page in bucket %s\n", bucket)
-			}
-			break
-		}
+// interestingKey reports whether an object key has an -interesting-ext
+// extension
+func interestingKey(key string) bool {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(key)), ".")
+	return ext != "" && interestingExts[ext]
+}
 
-		for _, object := range page.Contents {
-			if aggressive {
-				putObjectACP(ctx, client, bucket, *object.Key)
-			}
-			if verbose {
-				fmt.Printf("Checking ACP on %s/%s\n", bucket, *object.Key)
-			}
+// report shows a finding, on stdout or in the TUI, and records it for any
+// structured output that was requested. Findings below -severity are
+// dropped from all of them.
+func report(f warden.Finding) {
+	if belowSeverity(f) {
+		return
+	}
 
-			// Get the ACL for each object
-			aclOutput, err := client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
-				Bucket: aws.String(bucket),
-				Key:    object.Key,
-			})
-			if err != nil {
-				if verbose {
-					fmt.Printf("Failed to get ACL for object %s/%s\n", bucket, *object.Key)
-				}
-				continue
-			}
+	switch {
+	case printOnly != "":
+		printOnlyFinding(f)
+	case jsonOutput:
+		recordJSON(f)
+	case csvOutput:
+		writeCSVFinding(f)
+	case existsOnly:
+		printExists(f)
+	case tuiEnabled:
+		tuiProgram.Send(tuiFindingMsg(f))
+		writeOutput(findingText(f, f.Message))
+	default:
+		printFinding(f)
+	}
 
-			// Check if the ACL includes permissions by unauthorized users
-			// Initialize flags to track found permissions
-			hasPublicRead := false
-			hasPublicWrite := false
-
-			for _, grant := range aclOutput.Grants {
-				if grant.Grantee.Type == types.TypeGroup && *grant.Grantee.URI == "http://acs.amazonaws.com/groups/global/AllUsers" {
-					switch grant.Permission {
-					case types.PermissionRead:
-						hasPublicRead = true
-					case types.PermissionWrite, types.PermissionFullControl:
-						hasPublicWrite = true
-					}
-				}
-			}
+	summary.record(f)
 
-			// Decide what to print based on the flags
-			if hasPublicWrite {
-				if verbose {
-					color.Red.Printf("Object with public write access found: %s/%s\n", bucket, *object.Key)
-				} else {
-					fmt.Printf("Object with public write access found: %s/%s\n", bucket, *object.Key)
-				}
-				issueCounter++
-				if issueCounter >= 5 {
-					if verbose {
-						fmt.Printf("Found 5 objects with public write permissions in %s, skipping the rest.\n", bucket)
-					}
-					return
-				}
-			}
+	if socketPath != "" {
+		sendSocketFinding(f)
+	}
 
-			if hasPublicRead {
-				if verbose {
-					color.Yellow.Printf("Object with public read access found: %s/%s\n", bucket, *object.Key)
-				} else {
-					fmt.Printf("Object with public read access found: %s/%s\n", bucket, *object.Key)
-				}
-			}
+	if asffFile != "" {
+		recordASFF(f)
+	}
+
+	if accountRollupEnabled {
+		recordAccountFinding(f)
+	}
+}
+
+// findingText adds the scope, and when verbose the grant, to a finding's
+// message
+func findingText(f warden.Finding, message string) string {
+	if f.Scope != "" {
+		message += " [" + f.Scope + "]"
+	}
+	if f.Sensitive {
+		message += " [sensitive]"
+	}
+	if verbose && f.Grantee != "" {
+		message += fmt.Sprintf(" (%s granted %s via %s)", warden.Printable(f.Grantee), warden.Printable(f.Permission), f.Source)
+	}
+	if verbose && f.ContentType != "" {
+		message += fmt.Sprintf(" (%s)", f.ContentType)
+	}
+	return message
+}
 
+// printFinding writes a finding to stdout, in colour when verbose, and in
+// full to the -o file. Sensitive objects, and those with an -interesting-ext
+// extension, stand out in colours of their own.
+func printFinding(f warden.Finding) {
+	message := findingText(f, displayMessage(f))
+	c, ok := findingColors[f.Kind]
+	switch {
+	case f.Sensitive:
+		c, ok = sensitiveColor, true
+	case f.Key != "" && interestingKey(f.Key):
+		c, ok = interestingColor, true
+	}
+	if verbose && ok {
+		c.Println(message)
+	} else {
+		fmt.Println(message)
+	}
+	writeOutput(findingText(f, f.Message))
+}
+
+// readLines returns the non-empty lines of a file
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
 		}
 	}
+	return lines, scanner.Err()
 }
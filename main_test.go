@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(path, []byte(".env\n\nbackup.zip\nconfig.json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readLines(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".env", "backup.zip", "config.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readLines() = %v, want %v", got, want)
+	}
+
+	if _, err := readLines(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("readLines() on a missing file returned no error")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	// outputMu keeps lines from findings reported at the same time whole
+	outputMu   sync.Mutex
+	outputFile *os.File
+
+	// outputAppended is set when the -o file already held results
+	outputAppended bool
+)
+
+// openOutput opens the -o file, appending to it so that earlier reports
+// are kept
+func openOutput(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		outputAppended = true
+	}
+	outputFile = file
+	return nil
+}
+
+// writeOutput copies a line of results to the -o file, if one is open
+func writeOutput(line string) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	if outputFile == nil {
+		return
+	}
+	if _, err := fmt.Fprintln(outputFile, line); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write to %s, no longer writing results to it, %v\n", outputFile.Name(), err)
+		outputFile.Close()
+		outputFile = nil
+	}
+}
+
+func closeOutput() {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	if outputFile != nil {
+		outputFile.Close()
+	}
+	outputFile = nil
+}
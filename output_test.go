@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteOutputAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.txt")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := openOutput(path); err != nil {
+		t.Fatal(err)
+	}
+	writeOutput("first")
+	writeOutput("second")
+	closeOutput()
+	writeOutput("after close")
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "earlier\nfirst\nsecond\n"; string(got) != want {
+		t.Errorf("output file = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// printOwners writes the unique owners and their bucket counts to stderr
+func printOwners(owners map[string]int) {
+	labels := make([]string, 0, len(owners))
+	for label := range owners {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	fmt.Fprintf(os.Stderr, "Unique owners and accounts discovered: %d\n", len(labels))
+	for _, label := range labels {
+		fmt.Fprintf(os.Stderr, "  %s owns %d bucket(s)\n", warden.Printable(label), owners[label])
+	}
+}
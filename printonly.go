@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+var (
+	printedMu      sync.Mutex
+	printedBuckets = map[string]bool{}
+)
+
+func isFindingKind(kind string) bool {
+	for _, k := range warden.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// firstPrint reports whether a bucket is being printed for the first time,
+// since object findings can name the same bucket many times
+func firstPrint(bucket string) bool {
+	printedMu.Lock()
+	defer printedMu.Unlock()
+	if printedBuckets[bucket] {
+		return false
+	}
+	printedBuckets[bucket] = true
+	return true
+}
+
+// printOnlyFinding prints the bucket name of a finding of the -print-only
+// kind, once per bucket
+func printOnlyFinding(f warden.Finding) {
+	if f.Kind == printOnly && firstPrint(f.Bucket) {
+		fmt.Println(warden.Printable(f.Bucket))
+		writeOutput(warden.Printable(f.Bucket))
+	}
+}
+
+// existsLine is the -exists output for a bucket that exists
+func existsLine(f warden.Finding) string {
+	return "exists\t" + warden.Printable(f.Bucket)
+}
+
+// printExists prints each bucket -exists found, one per line
+func printExists(f warden.Finding) {
+	if f.Kind != warden.KindExists {
+		return
+	}
+	fmt.Println(existsLine(f))
+	writeOutput(existsLine(f))
+}
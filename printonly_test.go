@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestIsFindingKind(t *testing.T) {
+	tests := []struct {
+		kind string
+		want bool
+	}{
+		{"public-write", true},
+		{"object-acl-outlier", true},
+		{"public", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isFindingKind(tt.kind); got != tt.want {
+			t.Errorf("isFindingKind(%q) = %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestFirstPrint(t *testing.T) {
+	if !firstPrint("print-once") {
+		t.Error("first print of a bucket should be allowed")
+	}
+	if firstPrint("print-once") {
+		t.Error("second print of a bucket should be suppressed")
+	}
+}
+
+func TestExistsLine(t *testing.T) {
+	got := existsLine(warden.Finding{Bucket: "my-bucket", Kind: "exists"})
+	if want := "exists\tmy-bucket"; got != want {
+		t.Errorf("existsLine() = %q, want %q", got, want)
+	}
+}
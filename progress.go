@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// progressInterval is how often -progress reports, frequent enough to show
+// the scan is moving without flooding stderr on a long run
+const progressInterval = 5 * time.Second
+
+// scanProgress counts the buckets finished against the total, when the
+// total is known from an input file
+type scanProgress struct {
+	done  atomic.Int64
+	total int64
+}
+
+var progress scanProgress
+
+func (p *scanProgress) line() string {
+	if p.total > 0 {
+		done := p.done.Load()
+		return fmt.Sprintf("Scanned %d/%d buckets (%.1f%%)", done, p.total, 100*float64(done)/float64(p.total))
+	}
+	return fmt.Sprintf("Scanned %d buckets", p.done.Load())
+}
+
+// start writes the progress to w every interval until the returned function
+// is called, which writes it one last time
+func (p *scanProgress) start(w io.Writer, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	stopped := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintln(w, p.line())
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(stopped)
+		<-finished
+		fmt.Fprintln(w, p.line())
+	}
+}
+
+// countTargets counts the buckets in an input file, so progress can be
+// shown against a total
+func countTargets(path string, format string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	targets := make(chan warden.Target)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- readTargets(file, format, targets)
+		close(targets)
+	}()
+
+	var count int64
+	for range targets {
+		count++
+	}
+	return count, <-errs
+}
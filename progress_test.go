@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanProgressLine(t *testing.T) {
+	p := &scanProgress{total: 200}
+	p.done.Store(50)
+	if got, want := p.line(), "Scanned 50/200 buckets (25.0%)"; got != want {
+		t.Errorf("line() = %q, want %q", got, want)
+	}
+
+	p = &scanProgress{}
+	p.done.Store(7)
+	if got, want := p.line(), "Scanned 7 buckets"; got != want {
+		t.Errorf("line() = %q, want %q", got, want)
+	}
+}
+
+func TestScanProgressStartWritesFinalLine(t *testing.T) {
+	p := &scanProgress{total: 2}
+	var out bytes.Buffer
+	stop := p.start(&out, time.Hour)
+	p.done.Add(2)
+	stop()
+
+	if got := strings.TrimSpace(out.String()); got != "Scanned 2/2 buckets (100.0%)" {
+		t.Errorf("output = %q, want just the final count", got)
+	}
+}
+
+func TestCountTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buckets.txt")
+	if err := os.WriteFile(path, []byte("one\n\ntwo\n  \nthree\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := countTargets(path, "lines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("countTargets = %d, want 3", count)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// severityRank orders the ASFF severity labels used on findings, lowest
+// first
+var severityRank = map[string]int{
+	"INFORMATIONAL": 0,
+	"LOW":           1,
+	"MEDIUM":        2,
+	"HIGH":          3,
+	"CRITICAL":      4,
+}
+
+// minSeverity is the rank of the -severity flag, below which findings are
+// dropped
+var minSeverity int
+
+// parseSeverity returns the rank of a -severity value, in any case. Only
+// the severities the scanner gives findings are accepted.
+func parseSeverity(name string) (int, error) {
+	rank, ok := severityRank[strings.ToUpper(name)]
+	if !ok || rank > severityRank["HIGH"] {
+		return 0, fmt.Errorf("unknown severity %q, use informational, low, medium or high", name)
+	}
+	return rank, nil
+}
+
+// belowSeverity reports whether f should be dropped for -severity
+func belowSeverity(f warden.Finding) bool {
+	return severityRank[f.Severity] < minSeverity
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for name, want := range map[string]int{"informational": 0, "low": 1, "Medium": 2, "HIGH": 3} {
+		got, err := parseSeverity(name)
+		if err != nil || got != want {
+			t.Errorf("parseSeverity(%q) = %d, %v, want %d", name, got, err, want)
+		}
+	}
+	if _, err := parseSeverity("critical"); err == nil {
+		t.Error("parseSeverity(critical) succeeded, want an error")
+	}
+}
+
+func TestBelowSeverity(t *testing.T) {
+	old := minSeverity
+	defer func() { minSeverity = old }()
+	minSeverity = severityRank["MEDIUM"]
+
+	for severity, want := range map[string]bool{"INFORMATIONAL": true, "LOW": true, "MEDIUM": false, "HIGH": false} {
+		if got := belowSeverity(warden.Finding{Severity: severity}); got != want {
+			t.Errorf("belowSeverity(%s) = %v, want %v", severity, got, want)
+		}
+	}
+}
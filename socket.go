@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+var (
+	socketConn    net.Conn
+	socketEncoder *json.Encoder
+)
+
+// openSocket connects to a Unix domain socket that a collector, such as a
+// sidecar container, is listening on
+func openSocket(path string) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return err
+	}
+	socketConn = conn
+	socketEncoder = json.NewEncoder(conn)
+	return nil
+}
+
+// sendSocketFinding writes a finding to the socket as one line of JSON.
+// If the collector goes away the scan carries on without it.
+func sendSocketFinding(f warden.Finding) {
+	if socketEncoder == nil {
+		return
+	}
+	if err := socketEncoder.Encode(f); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write to the findings socket, no longer sending findings to it, %v\n", err)
+		closeSocket()
+	}
+}
+
+func closeSocket() {
+	if socketConn != nil {
+		socketConn.Close()
+	}
+	socketConn = nil
+	socketEncoder = nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestSendSocketFinding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	if err := openSocket(path); err != nil {
+		t.Fatal(err)
+	}
+	sendSocketFinding(warden.Finding{Bucket: "one", Kind: "public-read", Severity: "MEDIUM", Message: "read"})
+	sendSocketFinding(warden.Finding{Bucket: "two", Key: "k", Kind: "object-public-read", Severity: "MEDIUM", Message: "object"})
+	closeSocket()
+
+	var got []warden.Finding
+	for line := range lines {
+		var f warden.Finding
+		if err := json.Unmarshal([]byte(line), &f); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		got = append(got, f)
+	}
+	if len(got) != 2 || got[0].Bucket != "one" || got[1].Key != "k" {
+		t.Errorf("received %+v, want both findings in order", got)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// printRunStats writes how long the scan took and the rate it worked at
+func printRunStats(w io.Writer, elapsed time.Duration, stats warden.Stats) {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1e-9
+	}
+	fmt.Fprintf(w, "Scanned %d bucket(s) and checked %d object(s) in %s (%.1f buckets/s, %.1f objects/s)\n",
+		stats.Buckets, stats.Objects, elapsed.Round(time.Millisecond),
+		float64(stats.Buckets)/seconds, float64(stats.Objects)/seconds)
+
+	if stats.RetryBudget > 0 {
+		fmt.Fprintf(w, "Used %d of %d retries in the retry budget\n", stats.Retries, stats.RetryBudget)
+	} else if stats.Retries > 0 {
+		fmt.Fprintf(w, "Made %d retries\n", stats.Retries)
+	}
+	if stats.Throttled > 0 {
+		fmt.Fprintf(w, "%d request(s) failed because S3 kept throttling them, so findings may be missing. Try a lower -c or a higher -max-attempts\n", stats.Throttled)
+	}
+	if line := lookupFailureLine(stats.RegionLookupFailures); line != "" {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// lookupFailureLine sums up the failed region lookups, most common first,
+// e.g. "42 bucket(s) failed region lookup: 40 bucket does not exist, 2 timed out"
+func lookupFailureLine(failures map[string]int64) string {
+	if len(failures) == 0 {
+		return ""
+	}
+	reasons := make([]string, 0, len(failures))
+	var total int64
+	for reason, count := range failures {
+		reasons = append(reasons, reason)
+		total += count
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if failures[reasons[i]] != failures[reasons[j]] {
+			return failures[reasons[i]] > failures[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%d %s", failures[reason], reason)
+	}
+	return fmt.Sprintf("%d bucket(s) failed region lookup: %s", total, strings.Join(parts, ", "))
+}
+
+// publicRead and publicWrite group the kinds that open a bucket to reads or
+// writes by anyone, whether through an ACL or a policy
+func publicRead(kind string) bool {
+	return kind == warden.KindPublicRead || kind == warden.KindPolicyPublic
+}
+
+func publicWrite(kind string) bool {
+	return kind == warden.KindPublicWrite || kind == warden.KindUploadAllowed || kind == warden.KindPolicyPublicWrite
+}
+
+// runSummary counts the buckets with each kind of exposure, and the failed
+// calls, as findings arrive
+type runSummary struct {
+	mu          sync.Mutex
+	publicRead  map[string]bool
+	publicWrite map[string]bool
+	openListing map[string]bool
+	writableACP map[string]bool
+
+	// exposure is the worst public access found, for the exit code
+	exposure exposure
+
+	errors atomic.Int64
+}
+
+var summary = newRunSummary()
+
+func newRunSummary() *runSummary {
+	return &runSummary{
+		publicRead:  map[string]bool{},
+		publicWrite: map[string]bool{},
+		openListing: map[string]bool{},
+		writableACP: map[string]bool{},
+	}
+}
+
+func (s *runSummary) record(f warden.Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := findingExposure(f); e > s.exposure {
+		s.exposure = e
+	}
+
+	switch {
+	case publicRead(f.Kind):
+		s.publicRead[f.Bucket] = true
+	case publicWrite(f.Kind):
+		s.publicWrite[f.Bucket] = true
+	case f.Kind == warden.KindOpenListing:
+		s.openListing[f.Bucket] = true
+	case f.Kind == warden.KindWritableACP:
+		s.writableACP[f.Bucket] = true
+	}
+}
+
+// print writes the bucket counts and errors, after the run stats
+func (s *runSummary) print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(w, "Public read: %d bucket(s), public write: %d, open listing: %d, writable ACP: %d, errors: %d\n",
+		len(s.publicRead), len(s.publicWrite), len(s.openListing), len(s.writableACP), s.errors.Load())
+}
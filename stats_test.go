@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestPrintRunStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		stats   warden.Stats
+		want    string
+	}{
+		{"rates", 4 * time.Second, warden.Stats{Buckets: 10, Objects: 200}, "Scanned 10 bucket(s) and checked 200 object(s) in 4s (2.5 buckets/s, 50.0 objects/s)\n"},
+		{"nothing scanned", 0, warden.Stats{}, "Scanned 0 bucket(s) and checked 0 object(s) in 0s (0.0 buckets/s, 0.0 objects/s)\n"},
+		{"retry budget", time.Second, warden.Stats{Buckets: 1, Retries: 40, RetryBudget: 100}, "Scanned 1 bucket(s) and checked 0 object(s) in 1s (1.0 buckets/s, 0.0 objects/s)\nUsed 40 of 100 retries in the retry budget\n"},
+		{"retries without a budget", time.Second, warden.Stats{Buckets: 1, Retries: 3}, "Scanned 1 bucket(s) and checked 0 object(s) in 1s (1.0 buckets/s, 0.0 objects/s)\nMade 3 retries\n"},
+		{"throttled", time.Second, warden.Stats{Buckets: 1, Throttled: 2}, "Scanned 1 bucket(s) and checked 0 object(s) in 1s (1.0 buckets/s, 0.0 objects/s)\n2 request(s) failed because S3 kept throttling them, so findings may be missing. Try a lower -c or a higher -max-attempts\n"},
+		{"region lookups", time.Second, warden.Stats{Buckets: 1, RegionLookupFailures: map[string]int64{"timed out": 2, "bucket does not exist": 40, "access denied": 2}}, "Scanned 1 bucket(s) and checked 0 object(s) in 1s (1.0 buckets/s, 0.0 objects/s)\n44 bucket(s) failed region lookup: 40 bucket does not exist, 2 access denied, 2 timed out\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			printRunStats(&b, tt.elapsed, tt.stats)
+			if got := b.String(); got != tt.want {
+				t.Errorf("printRunStats() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	s := newRunSummary()
+	for _, f := range []warden.Finding{
+		{Bucket: "a", Kind: "public-read"},
+		{Bucket: "a", Kind: "policy-public"},
+		{Bucket: "b", Kind: "upload-allowed"},
+		{Bucket: "b", Kind: "open-listing"},
+		{Bucket: "b", Kind: "writable-acp"},
+		{Bucket: "c", Kind: "object-public-read", Key: "k"},
+	} {
+		s.record(f)
+	}
+	s.errors.Add(2)
+
+	var b strings.Builder
+	s.print(&b)
+	want := "Public read: 1 bucket(s), public write: 1, open listing: 1, writable ACP: 1, errors: 2\n"
+	if got := b.String(); got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+// tuiSeverities are the minimum severities the TUI filter cycles through
+var tuiSeverities = []string{"INFORMATIONAL", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+type (
+	tuiFindingMsg    warden.Finding
+	tuiBucketDoneMsg struct{}
+	tuiScanDoneMsg   struct{}
+)
+
+// tuiModel is a live view of the finding stream, filterable by minimum
+// severity and by finding kind
+type tuiModel struct {
+	findings    []warden.Finding
+	counts      map[string]int
+	kinds       []string
+	scanned     int
+	done        bool
+	minSeverity int
+	kind        int // index into kinds, or -1 for every kind
+	height      int
+}
+
+var (
+	tuiProgram  *tea.Program
+	tuiExited   = make(chan struct{})
+	scanRunning atomic.Bool
+)
+
+// stdoutIsTerminal reports whether stdout is attached to a terminal
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && (info.Mode()&os.ModeCharDevice) != 0
+}
+
+// startTUI runs the TUI while the scan is in progress. Keys are read from
+// the terminal since stdin carries the bucket list. Quitting before the
+// scan finishes calls quit, which abandons it the way a second Ctrl-C
+// would, so test grants and uploads are still cleaned up and the findings
+// so far are written.
+func startTUI(quit func()) {
+	tuiProgram = tea.NewProgram(tuiModel{counts: map[string]int{}, kind: -1, height: 24}, tea.WithInputTTY())
+	scanRunning.Store(true)
+
+	go func() {
+		defer close(tuiExited)
+		if _, err := tuiProgram.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "TUI failed, %v\n", err)
+		}
+		if scanRunning.Load() {
+			logf(levelWarn, "Quit, abandoning the buckets in progress")
+			quit()
+		}
+	}()
+}
+
+// finishTUI tells the TUI the scan is complete and waits for the user to quit
+func finishTUI() {
+	scanRunning.Store(false)
+	tuiProgram.Send(tuiScanDoneMsg{})
+	<-tuiExited
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tuiFindingMsg:
+		f := warden.Finding(msg)
+		m.findings = append(m.findings, f)
+		if m.counts[f.Kind] == 0 {
+			m.kinds = append(m.kinds, f.Kind)
+		}
+		m.counts[f.Kind]++
+	case tuiBucketDoneMsg:
+		m.scanned++
+	case tuiScanDoneMsg:
+		m.done = true
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "s":
+			m.minSeverity = (m.minSeverity + 1) % len(tuiSeverities)
+		case "t":
+			m.kind++
+			if m.kind >= len(m.kinds) {
+				m.kind = -1
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m tuiModel) visible(f warden.Finding) bool {
+	if severityRank[f.Severity] < m.minSeverity {
+		return false
+	}
+	return m.kind < 0 || f.Kind == m.kinds[m.kind]
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+
+	status := "scanning"
+	if m.done {
+		status = "complete"
+	}
+	fmt.Fprintf(&b, "s3-warden  %s  buckets: %d  findings: %d\n", status, m.scanned, len(m.findings))
+
+	kind := "all"
+	if m.kind >= 0 {
+		kind = m.kinds[m.kind]
+	}
+	fmt.Fprintf(&b, "severity >= %s  type: %s  [s] severity  [t] type  [q] quit\n\n", tuiSeverities[m.minSeverity], kind)
+
+	var shown []warden.Finding
+	for _, f := range m.findings {
+		if m.visible(f) {
+			shown = append(shown, f)
+		}
+	}
+
+	// keep the newest findings that fit under the header
+	if rows := m.height - 4; rows > 0 && len(shown) > rows {
+		shown = shown[len(shown)-rows:]
+	}
+	for _, f := range shown {
+		fmt.Fprintf(&b, "%-13s %-20s %s\n", f.Severity, f.Kind, displayMessage(f))
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cybercdh/s3-warden/warden"
+)
+
+func TestTUIModelFilters(t *testing.T) {
+	var model tea.Model = tuiModel{counts: map[string]int{}, kind: -1, height: 24}
+	model, _ = model.Update(tuiFindingMsg(warden.Finding{Kind: "public-write", Severity: "HIGH", Message: "write finding"}))
+	model, _ = model.Update(tuiFindingMsg(warden.Finding{Kind: "exists", Severity: "INFORMATIONAL", Message: "exists finding"}))
+	model, _ = model.Update(tuiBucketDoneMsg{})
+
+	view := model.View()
+	if !strings.Contains(view, "write finding") || !strings.Contains(view, "exists finding") {
+		t.Fatalf("unfiltered view missing findings:\n%s", view)
+	}
+	if !strings.Contains(view, "buckets: 1") {
+		t.Errorf("view missing bucket count:\n%s", view)
+	}
+
+	// raise the minimum severity past INFORMATIONAL
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if view := model.View(); strings.Contains(view, "exists finding") {
+		t.Errorf("severity filter kept an INFORMATIONAL finding:\n%s", view)
+	}
+
+	// cycle the type filter to the first kind seen
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	view = model.View()
+	if !strings.Contains(view, "type: public-write") || strings.Contains(view, "exists finding") {
+		t.Errorf("type filter not applied:\n%s", view)
+	}
+
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Errorf("q did not quit")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// parseS3URL splits s3://bucket/prefix into the bucket and a key prefix
+// that ends in "/" unless it is empty
+func parseS3URL(url string) (bucket string, prefix string, err error) {
+	if !strings.HasPrefix(url, "s3://") {
+		return "", "", fmt.Errorf("invalid S3 URL %q, use s3://bucket/prefix", url)
+	}
+	bucket, prefix, _ = strings.Cut(strings.TrimPrefix(url, "s3://"), "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q, no bucket given", url)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return bucket, prefix, nil
+}
+
+// uploadReports copies the report files written by this run to the S3
+// location given by -report-upload, keeping their file names
+func uploadReports(ctx context.Context, url string, paths []string) error {
+	bucket, prefix, err := parseS3URL(url)
+	if err != nil {
+		return err
+	}
+
+	// the reports go to the same store the scan used, with -endpoint
+	client, err := scanner.BucketClient(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := uploadReport(ctx, client, bucket, prefix+filepath.Base(path), path); err != nil {
+			return fmt.Errorf("unable to upload %s, %v", path, err)
+		}
+	}
+	return nil
+}
+
+// reportContentType picks the content type of a report from its
+// extension, such as text/csv for -csv output. The system's MIME table may
+// not know .csv or .ndjson, so the report formats are named here. Every
+// report is text, so anything unrecognised is uploaded as plain text.
+func reportContentType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".json":
+		return "application/json"
+	case ".ndjson", ".jsonl":
+		return "application/x-ndjson"
+	case ".csv":
+		return "text/csv; charset=utf-8"
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func uploadReport(ctx context.Context, client *s3.Client, bucket string, key string, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String(reportContentType(path)),
+	})
+	return err
+}
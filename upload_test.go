@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestParseS3URL(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantBucket string
+		wantPrefix string
+		wantErr    bool
+	}{
+		{"s3://reports", "reports", "", false},
+		{"s3://reports/", "reports", "", false},
+		{"s3://reports/scans/2024", "reports", "scans/2024/", false},
+		{"s3://reports/scans/", "reports", "scans/", false},
+		{"https://reports.s3.amazonaws.com", "", "", true},
+		{"s3:///scans", "", "", true},
+	}
+
+	for _, tt := range tests {
+		bucket, prefix, err := parseS3URL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseS3URL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if bucket != tt.wantBucket || prefix != tt.wantPrefix {
+			t.Errorf("parseS3URL(%q) = %q, %q, want %q, %q", tt.url, bucket, prefix, tt.wantBucket, tt.wantPrefix)
+		}
+	}
+}
+
+func TestReportContentType(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"findings-1.json", "application/json"},
+		{"scan.CSV", "text/csv; charset=utf-8"},
+		{"scan.ndjson", "application/x-ndjson"},
+		{"scan", "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		if got := reportContentType(tt.path); got != tt.want {
+			t.Errorf("reportContentType(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+)
+
+// version and commit are set at build time with
+// -ldflags "-X main.version=v1.2.3 -X main.commit=abc1234"
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// versionString describes the build, falling back to the module version and
+// VCS revision Go records when the ldflags weren't set, as with go install
+func versionString() string {
+	v, c := version, commit
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		if c == "" {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					c = setting.Value
+				}
+			}
+		}
+	}
+	if len(c) > 7 {
+		c = c[:7]
+	}
+	if c == "" {
+		return v
+	}
+	return v + " (" + c + ")"
+}
+
+// printBanner writes the version and the settings that most change what a
+// scan reports, so a saved log shows how it was run
+func printBanner(w io.Writer) {
+	mode := "full"
+	if quick {
+		mode = "quick"
+	}
+	settings := []string{"mode " + mode, fmt.Sprintf("concurrency %d", concurrency)}
+	if aggressive {
+		settings = append(settings, "aggressive")
+	}
+	if verify {
+		settings = append(settings, "verify")
+	}
+	if keysFile != "" {
+		settings = append(settings, "keys from "+keysFile)
+	}
+	fmt.Fprintf(w, "s3-warden %s, %s\n", versionString(), strings.Join(settings, ", "))
+}
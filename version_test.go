@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVersionStringUsesLdflags(t *testing.T) {
+	oldVersion, oldCommit := version, commit
+	defer func() { version, commit = oldVersion, oldCommit }()
+
+	version, commit = "v1.2.3", "0123456789abcdef"
+	if got, want := versionString(), "v1.2.3 (0123456)"; got != want {
+		t.Errorf("versionString() = %q, want %q", got, want)
+	}
+}
+
+func TestPrintBanner(t *testing.T) {
+	oldQuick, oldAggressive, oldConcurrency := quick, aggressive, concurrency
+	defer func() { quick, aggressive, concurrency = oldQuick, oldAggressive, oldConcurrency }()
+
+	quick, aggressive, concurrency = true, true, 4
+	var b bytes.Buffer
+	printBanner(&b)
+
+	got := b.String()
+	for _, want := range []string{"s3-warden ", "mode quick", "concurrency 4", "aggressive"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("banner %q does not contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// disabledBlocks lists the public access block settings that are off
+func disabledBlocks(block *types.PublicAccessBlockConfiguration) []string {
+	var disabled []string
+	settings := []struct {
+		name    string
+		enabled *bool
+	}{
+		{"BlockPublicAcls", block.BlockPublicAcls},
+		{"IgnorePublicAcls", block.IgnorePublicAcls},
+		{"BlockPublicPolicy", block.BlockPublicPolicy},
+		{"RestrictPublicBuckets", block.RestrictPublicBuckets},
+	}
+	for _, setting := range settings {
+		if !aws.ToBool(setting.enabled) {
+			disabled = append(disabled, setting.name)
+		}
+	}
+	return disabled
+}
+
+// checkPublicAccessBlock reports a bucket with no public access block, or
+// one with some of its settings off. With IgnorePublicAcls on, public ACL
+// grants found elsewhere have no effect.
+func (s *Scanner) checkPublicAccessBlock(ctx context.Context, client *s3.Client, bucket string) {
+	output, err := client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchPublicAccessBlockConfiguration" {
+			s.report(Finding{
+				Bucket:   bucket,
+				Region:   client.Options().Region,
+				Kind:     KindPublicAccessBlockMissing,
+				Severity: "LOW",
+				Message:  fmt.Sprintf("No public access block on bucket %s", Printable(bucket)),
+			})
+			return
+		}
+		s.logFailure(err, "Failed to get the public access block for %s", Printable(bucket))
+		return
+	}
+	if output.PublicAccessBlockConfiguration == nil {
+		return
+	}
+
+	disabled := disabledBlocks(output.PublicAccessBlockConfiguration)
+	if len(disabled) == 0 {
+		s.log.Debugf("Bucket %s blocks all public access", Printable(bucket))
+		return
+	}
+	s.report(Finding{
+		Bucket:   bucket,
+		Region:   client.Options().Region,
+		Kind:     KindPublicAccessBlockPartial,
+		Severity: "INFORMATIONAL",
+		Message:  fmt.Sprintf("Public access block on bucket %s leaves %s off", Printable(bucket), strings.Join(disabled, ", ")),
+	})
+}
@@ -0,0 +1,49 @@
+package warden
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestDisabledBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		block types.PublicAccessBlockConfiguration
+		want  []string
+	}{
+		{
+			name: "all on",
+			block: types.PublicAccessBlockConfiguration{
+				BlockPublicAcls:       aws.Bool(true),
+				IgnorePublicAcls:      aws.Bool(true),
+				BlockPublicPolicy:     aws.Bool(true),
+				RestrictPublicBuckets: aws.Bool(true),
+			},
+		},
+		{
+			name: "ACLs only",
+			block: types.PublicAccessBlockConfiguration{
+				BlockPublicAcls:   aws.Bool(true),
+				IgnorePublicAcls:  aws.Bool(true),
+				BlockPublicPolicy: aws.Bool(false),
+			},
+			want: []string{"BlockPublicPolicy", "RestrictPublicBuckets"},
+		},
+		{
+			name:  "empty",
+			block: types.PublicAccessBlockConfiguration{},
+			want:  []string{"BlockPublicAcls", "IgnorePublicAcls", "BlockPublicPolicy", "RestrictPublicBuckets"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := disabledBlocks(&tt.block); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("disabledBlocks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
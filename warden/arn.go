@@ -0,0 +1,39 @@
+package warden
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// resolveBucket turns an input name into what the S3 client should be given
+// as the bucket. Plain names are used as they are. A bucket ARN becomes its
+// bucket name, and an access point ARN is passed through for the SDK to
+// address, along with the region it names. Input the SDK can't address
+// without extra signing support, such as Multi-Region Access Points, is
+// rejected with a reason.
+func resolveBucket(name string) (bucket string, region string, err error) {
+	if strings.HasSuffix(name, ".mrap") {
+		return "", "", fmt.Errorf("multi-region access point aliases are not supported")
+	}
+	if !strings.HasPrefix(name, "arn:") {
+		return name, "", nil
+	}
+
+	parsed, err := arn.Parse(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch {
+	case parsed.Service == "s3" && parsed.Region == "" && parsed.AccountID == "" && !strings.Contains(parsed.Resource, "/"):
+		return parsed.Resource, "", nil
+	case parsed.Service == "s3" && strings.HasPrefix(parsed.Resource, "accesspoint/") && parsed.Region == "":
+		return "", "", fmt.Errorf("multi-region access points are not supported")
+	case parsed.Service == "s3" && strings.HasPrefix(parsed.Resource, "accesspoint/"):
+		return name, parsed.Region, nil
+	default:
+		return "", "", fmt.Errorf("unsupported ARN, only bucket and access point ARNs can be scanned")
+	}
+}
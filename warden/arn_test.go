@@ -0,0 +1,37 @@
+package warden
+
+import "testing"
+
+func TestResolveBucket(t *testing.T) {
+	accessPoint := "arn:aws:s3:eu-west-1:111111111111:accesspoint/reports"
+
+	tests := []struct {
+		name       string
+		in         string
+		wantBucket string
+		wantRegion string
+		wantErr    bool
+	}{
+		{"plain name", "my-bucket", "my-bucket", "", false},
+		{"access point alias", "reports-abc123-s3alias", "reports-abc123-s3alias", "", false},
+		{"bucket arn", "arn:aws:s3:::my-bucket", "my-bucket", "", false},
+		{"access point arn", accessPoint, accessPoint, "eu-west-1", false},
+		{"multi-region access point arn", "arn:aws:s3::111111111111:accesspoint/mfzwi23gnjvgw.mrap", "", "", true},
+		{"multi-region access point alias", "mfzwi23gnjvgw.mrap", "", "", true},
+		{"object arn", "arn:aws:s3:::my-bucket/key", "", "", true},
+		{"outpost arn", "arn:aws:s3-outposts:us-west-2:111111111111:outpost/op-01ac5d28a6a232904/accesspoint/ap", "", "", true},
+		{"malformed arn", "arn:aws:s3", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, region, err := resolveBucket(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveBucket(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if bucket != tt.wantBucket || region != tt.wantRegion {
+				t.Errorf("resolveBucket(%q) = %q, %q, want %q, %q", tt.in, bucket, region, tt.wantBucket, tt.wantRegion)
+			}
+		})
+	}
+}
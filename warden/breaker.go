@@ -0,0 +1,180 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// breakerRecoverAfter is the number of successful attempts in a row after
+// which a tripped breaker doubles its workers again, up to the concurrency
+// the scan started with
+const breakerRecoverAfter = 100
+
+// breaker counts consecutive throttled attempts. When threshold of them
+// land within window, every request is held for the cooldown and the
+// number of workers allowed to scan is halved. Once recoverAfter attempts
+// in a row succeed, the workers are doubled again.
+type breaker struct {
+	mu           sync.Mutex
+	threshold    int
+	window       time.Duration
+	cooldown     time.Duration
+	recoverAfter int
+	throttles    int
+	successes    int
+	first        time.Time
+	pausedUntil  time.Time
+
+	// slots holds one token per worker allowed to process a bucket
+	slots   chan struct{}
+	limit   int
+	workers int
+}
+
+func newBreaker(threshold int, window time.Duration, cooldown time.Duration, workers int) *breaker {
+	b := &breaker{
+		threshold:    threshold,
+		window:       window,
+		cooldown:     cooldown,
+		recoverAfter: breakerRecoverAfter,
+		slots:        make(chan struct{}, workers),
+		limit:        workers,
+		workers:      workers,
+	}
+	for i := 0; i < workers; i++ {
+		b.slots <- struct{}{}
+	}
+	return b
+}
+
+// acquire waits for a worker slot before a bucket is processed
+func (b *breaker) acquire() {
+	<-b.slots
+}
+
+// release hands a worker slot back once a bucket is done
+func (b *breaker) release() {
+	b.slots <- struct{}{}
+}
+
+// record notes the outcome of one attempt and reports whether it tripped
+// the breaker or gave workers back. Anything other than a throttle resets
+// the run of throttles, and a throttle resets the run of successes.
+func (b *breaker) record(err error, now time.Time) (tripped, restored bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isThrottled(err) {
+		b.throttles = 0
+		if err == nil {
+			b.successes++
+		}
+		if b.successes < b.recoverAfter || b.limit >= b.workers {
+			return false, false
+		}
+
+		b.successes = 0
+		restore := b.limit
+		if b.limit+restore > b.workers {
+			restore = b.workers - b.limit
+		}
+		b.limit += restore
+		// a worker still holding a slot that was shed may be returning it,
+		// so the slots are put back without holding the lock
+		go func() {
+			for i := 0; i < restore; i++ {
+				b.slots <- struct{}{}
+			}
+		}()
+		return false, true
+	}
+	b.successes = 0
+
+	if b.throttles == 0 || now.Sub(b.first) > b.window {
+		b.throttles = 0
+		b.first = now
+	}
+	b.throttles++
+	if b.throttles < b.threshold {
+		return false, false
+	}
+
+	b.throttles = 0
+	b.pausedUntil = now.Add(b.cooldown)
+	if b.limit > 1 {
+		shed := b.limit / 2
+		b.limit -= shed
+		// the slots are taken as workers return them, so a worker part
+		// way through a bucket isn't interrupted
+		go func() {
+			for i := 0; i < shed; i++ {
+				<-b.slots
+			}
+		}()
+	}
+	return true, false
+}
+
+// allowed returns the number of workers currently allowed to scan
+func (b *breaker) allowed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit
+}
+
+// wait holds a request until any cooldown has passed
+func (b *breaker) wait(ctx context.Context) error {
+	b.mu.Lock()
+	delay := time.Until(b.pausedUntil)
+	b.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// addBreakerMiddleware runs every attempt, including the SDK's retries,
+// through the breaker
+func (s *Scanner) addBreakerMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("S3WardenBreaker", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		if err := s.breaker.wait(ctx); err != nil {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+		}
+		out, metadata, err := next.HandleFinalize(ctx, in)
+		switch tripped, restored := s.breaker.record(err, time.Now()); {
+		case tripped:
+			s.log.Warnf("Throttled %d times in a row, pausing for %s and continuing with %d workers", s.breaker.threshold, s.breaker.cooldown, s.breaker.allowed())
+		case restored:
+			s.log.Warnf("No longer throttled, continuing with %d workers", s.breaker.allowed())
+		}
+		return out, metadata, err
+	}), "Retry", middleware.After)
+}
+
+// isThrottled reports whether S3 asked us to slow down
+func isThrottled(err error) bool {
+	if err == nil {
+		return false
+	}
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err).Bool() {
+		return true
+	}
+
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusServiceUnavailable
+}
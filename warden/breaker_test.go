@@ -0,0 +1,117 @@
+package warden
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"slow down", &smithy.GenericAPIError{Code: "SlowDown"}, true},
+		{"503", responseError(http.StatusServiceUnavailable), true},
+		{"access denied", &smithy.GenericAPIError{Code: "AccessDenied"}, false},
+		{"500", responseError(http.StatusInternalServerError), false},
+		{"plain", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isThrottled(tt.err); got != tt.want {
+				t.Errorf("isThrottled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerRecord(t *testing.T) {
+	slowDown := &smithy.GenericAPIError{Code: "SlowDown"}
+	start := time.Now()
+
+	tests := []struct {
+		name     string
+		attempts []error
+		spacing  time.Duration
+		want     bool
+	}{
+		{"below threshold", []error{slowDown, slowDown}, time.Second, false},
+		{"consecutive throttles", []error{slowDown, slowDown, slowDown}, time.Second, true},
+		{"reset by a success", []error{slowDown, slowDown, nil, slowDown}, time.Second, false},
+		{"outside the window", []error{slowDown, slowDown, slowDown}, 10 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(3, 15*time.Second, time.Minute, 4)
+			tripped := false
+			for i, err := range tt.attempts {
+				if hit, _ := b.record(err, start.Add(time.Duration(i)*tt.spacing)); hit {
+					tripped = true
+				}
+			}
+			if tripped != tt.want {
+				t.Errorf("tripped = %v, want %v", tripped, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerTripHalvesWorkers(t *testing.T) {
+	slowDown := &smithy.GenericAPIError{Code: "SlowDown"}
+	b := newBreaker(1, time.Minute, time.Minute, 4)
+
+	if tripped, _ := b.record(slowDown, time.Now()); !tripped {
+		t.Fatal("expected the breaker to trip")
+	}
+	if b.limit != 2 {
+		t.Errorf("limit = %d, want 2", b.limit)
+	}
+	if time.Until(b.pausedUntil) <= 0 {
+		t.Error("expected requests to be paused")
+	}
+
+	b.record(slowDown, time.Now())
+	b.record(slowDown, time.Now())
+	if b.limit != 1 {
+		t.Errorf("limit = %d, want it to stop at 1", b.limit)
+	}
+}
+
+func TestBreakerRecoversWorkers(t *testing.T) {
+	slowDown := &smithy.GenericAPIError{Code: "SlowDown"}
+	b := newBreaker(1, time.Minute, time.Minute, 4)
+	b.recoverAfter = 3
+
+	b.record(slowDown, time.Now())
+	b.record(slowDown, time.Now())
+	if b.limit != 1 {
+		t.Fatalf("limit = %d, want 1 after two trips", b.limit)
+	}
+
+	b.record(nil, time.Now())
+	b.record(nil, time.Now())
+	b.record(slowDown, time.Now())
+	b.record(nil, time.Now())
+	b.record(nil, time.Now())
+	if _, restored := b.record(nil, time.Now()); !restored {
+		t.Fatal("expected three successes in a row to restore workers")
+	}
+	if b.limit != 2 {
+		t.Errorf("limit = %d, want it doubled to 2", b.limit)
+	}
+
+	for i := 0; i < 6; i++ {
+		b.record(nil, time.Now())
+	}
+	if b.limit != 4 {
+		t.Errorf("limit = %d, want it back to the 4 workers the scan started with", b.limit)
+	}
+}
@@ -0,0 +1,819 @@
+package warden
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// regionLookupURL is the URL whose response names a bucket's region. A name
+// with dots isn't covered by the *.s3.amazonaws.com certificate, so it is
+// looked up path-style instead.
+func regionLookupURL(bucket string) string {
+	if strings.Contains(bucket, ".") {
+		return "https://s3.amazonaws.com/" + bucket
+	}
+	return fmt.Sprintf("https://%s.s3.amazonaws.com", bucket)
+}
+
+// getBucketRegion asks S3 for a bucket's region, resolving the S3 host with
+// resolver or connecting through proxy when one is given, and giving up
+// after timeout. Certificates are only left unverified when insecure is set.
+func getBucketRegion(ctx context.Context, bucket string, resolver *net.Resolver, proxy *url.URL, timeout time.Duration, insecure bool) (string, error) {
+	url := regionLookupURL(bucket)
+
+	customTransport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if resolver != nil {
+		dialer := &net.Dialer{Resolver: resolver}
+		customTransport.DialContext = dialer.DialContext
+	}
+	if proxy != nil {
+		customTransport.Proxy = http.ProxyURL(proxy)
+	}
+
+	client := &http.Client{Transport: customTransport, Timeout: timeout}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	region := resp.Header.Get("x-amz-bucket-region")
+	if region == "" {
+		return "", &regionStatusError{status: resp.StatusCode}
+	}
+	return region, nil
+}
+
+func (s *Scanner) checkOpenListing(ctx context.Context, client *s3.Client, bucket string) (bool, int) {
+	output, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		MaxKeys: aws.Int32(1),
+	})
+
+	if err != nil {
+		s.logFailure(err, "No open directory listing found in: %s", Printable(bucket))
+		return false, 0
+	}
+
+	finding := Finding{
+		Bucket:   bucket,
+		Region:   client.Options().Region,
+		Kind:     KindOpenListing,
+		Severity: "MEDIUM",
+		Message:  fmt.Sprintf("Possible open directory listing in %s", Printable(bucket)),
+	}
+	// the totals cost a request per 1000 objects, so are only counted when
+	// asked for
+	if s.opts.ListingSize {
+		size := s.measureListing(ctx, client, bucket)
+		finding.Message += fmt.Sprintf(" (%s)", size.text())
+		finding.ObjectCount = &size.objects
+		finding.TotalSize = &size.bytes
+	}
+	s.report(finding)
+	return true, len(output.Contents)
+}
+
+// AllUsersURI is the grantee of findings that come from an ACL grant to
+// everyone
+const AllUsersURI = "http://acs.amazonaws.com/groups/global/AllUsers"
+
+// AuthenticatedUsersURI is the group of anyone with AWS credentials, in
+// any account
+const AuthenticatedUsersURI = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+
+// aclSummary records which public permissions an ACL grants to AllUsers,
+// along with the permission that granted them, and the permissions it
+// grants to AuthenticatedUsers
+type aclSummary struct {
+	publicRead      bool
+	publicWrite     bool
+	readPermission  types.Permission
+	writePermission types.Permission
+
+	authenticated []types.Permission
+}
+
+func summarizeGrants(grants []types.Grant) aclSummary {
+	var summary aclSummary
+	for _, grant := range grants {
+		if grant.Grantee.Type != types.TypeGroup || grant.Grantee.URI == nil {
+			continue
+		}
+		switch *grant.Grantee.URI {
+		case AllUsersURI:
+			switch grant.Permission {
+			case types.PermissionRead:
+				summary.publicRead = true
+				summary.readPermission = grant.Permission
+			case types.PermissionWrite, types.PermissionFullControl:
+				summary.publicWrite = true
+				summary.writePermission = grant.Permission
+			}
+		case AuthenticatedUsersURI:
+			switch grant.Permission {
+			case types.PermissionRead, types.PermissionWrite, types.PermissionFullControl:
+				summary.authenticated = append(summary.authenticated, grant.Permission)
+			}
+		}
+	}
+	return summary
+}
+
+// isOutlier reports whether an object grants public access its bucket does not
+func isOutlier(object aclSummary, bucket aclSummary) bool {
+	return (object.publicRead && !bucket.publicRead) || (object.publicWrite && !bucket.publicWrite)
+}
+
+// objectScope describes a public object relative to its bucket, which
+// tells a responder whether one leaked file or the whole bucket is exposed
+func objectScope(bucket *aclSummary) string {
+	switch {
+	case bucket == nil:
+		return ""
+	case !bucket.publicRead && !bucket.publicWrite:
+		return "public object in private bucket"
+	default:
+		return "public object in public bucket"
+	}
+}
+
+// outlierSeverity rates an outlier higher when the bucket grants nothing public
+func outlierSeverity(bucket aclSummary) string {
+	if !bucket.publicRead && !bucket.publicWrite {
+		return "HIGH"
+	}
+	return "MEDIUM"
+}
+
+func (s *Scanner) checkBucketACL(ctx context.Context, client *s3.Client, bucket string) (aclSummary, error) {
+	aclOutput, err := client.GetBucketAcl(ctx, &s3.GetBucketAclInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		// a guessed region is retried by the caller, so don't report it
+		// yet, and a missing bucket is reported by the caller too
+		switch {
+		case s.opts.RegionGuess != "" && isRegionMismatch(err), isNoSuchBucket(err):
+		case isAccessDenied(err):
+			s.log.Infof("Access denied to the ACL of bucket %s, so it exists but is private", Printable(bucket))
+		default:
+			s.logFailure(err, "Failed to get ACL for bucket %s", Printable(bucket))
+		}
+		return aclSummary{}, err
+	}
+
+	s.recordOwner(bucket, client.Options().Region, aclOutput.Owner)
+	summary := summarizeGrants(aclOutput.Grants)
+
+	// Decide what to print based on the flags
+	if summary.publicWrite {
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindPublicWrite,
+			Severity: "HIGH",
+			Message:  fmt.Sprintf("Bucket with public write access found: %s", Printable(bucket)),
+
+			Grantee:    AllUsersURI,
+			Permission: string(summary.writePermission),
+			Source:     "acl",
+		})
+	}
+
+	if summary.publicRead {
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindPublicRead,
+			Severity: "MEDIUM",
+			Message:  fmt.Sprintf("Bucket with public read access found: %s", Printable(bucket)),
+
+			Grantee:    AllUsersURI,
+			Permission: string(summary.readPermission),
+			Source:     "acl",
+		})
+	}
+
+	// AuthenticatedUsers lets in any AWS account, not the bucket owner's
+	// users as the name suggests
+	for _, permission := range summary.authenticated {
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindAuthenticatedUsers,
+			Severity: "MEDIUM",
+			Message:  fmt.Sprintf("Bucket with %s access for any AWS account found: %s", permission, Printable(bucket)),
+
+			Grantee:    AuthenticatedUsersURI,
+			Permission: string(permission),
+			Source:     "acl",
+		})
+	}
+
+	s.reportOtherGrantees(bucket, client.Options().Region, aclOutput.Owner, aclOutput.Grants)
+
+	if !summary.publicRead && !summary.publicWrite && len(summary.authenticated) == 0 {
+		s.log.Debugf("No public access found on bucket %s", Printable(bucket))
+	}
+
+	return summary, nil
+}
+
+// skipWrite reports whether a write is skipped because of DryRun, logging
+// the call that would have been made and, when verbose, its parameters
+func (s *Scanner) skipWrite(operation string, target string, params string) bool {
+	if !s.opts.DryRun {
+		return false
+	}
+	s.log.Warnf("Dry run, not calling %s on %s", operation, target)
+	s.log.Debugf("Dry run %s parameters: %s", operation, params)
+	return true
+}
+
+func (s *Scanner) testUpload(ctx context.Context, client *s3.Client, bucket string, key string, body *strings.Reader) bool {
+	if s.skipWrite("PutObject", Printable(bucket)+"/"+Printable(key), fmt.Sprintf("Bucket=%s Key=%s Body=%d bytes", Printable(bucket), Printable(key), body.Len())) {
+		return false
+	}
+	s.log.Debugf("Attempting to upload file to %s", Printable(bucket))
+	_, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	})
+	if err != nil {
+		return false
+	}
+	s.report(Finding{
+		Bucket:   bucket,
+		Region:   client.Options().Region,
+		Kind:     KindUploadAllowed,
+		Severity: "HIGH",
+		Message:  fmt.Sprintf("Upload allowed in bucket %s", Printable(bucket)),
+	})
+
+	if s.opts.NoCleanup {
+		s.log.Debugf("Leaving %s/%s in place", Printable(bucket), Printable(key))
+		return true
+	}
+	// the upload is deleted even once the scan has been cancelled
+	cleanupCtx, cancel := context.WithTimeout(withoutCancel{ctx}, cleanupTimeout)
+	defer cancel()
+	_, err = client.DeleteObject(cleanupCtx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.log.Debugf("Failed to delete the test upload %s/%s, it is still in the bucket", Printable(bucket), Printable(key))
+	} else {
+		s.log.Debugf("Deleted the test upload %s/%s", Printable(bucket), Printable(key))
+	}
+	return true
+}
+
+// cleanupTimeout bounds undoing a write once the scan's own context is
+// no longer followed
+const cleanupTimeout = 30 * time.Second
+
+// withoutCancel keeps a context's values but not its deadline or
+// cancellation, so a write can still be undone after a scan is cancelled
+type withoutCancel struct {
+	context.Context
+}
+
+func (withoutCancel) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (withoutCancel) Done() <-chan struct{}       { return nil }
+func (withoutCancel) Err() error                  { return nil }
+
+// testGrant is added to a bucket or object ACL to test whether it is
+// writable. It grants no more than READ to AWS users, never to everyone.
+var testGrant = types.Grant{
+	Grantee:    &types.Grantee{Type: types.TypeGroup, URI: aws.String(AuthenticatedUsersURI)},
+	Permission: types.PermissionRead,
+}
+
+// writeBucketACP tests whether a bucket's ACL can be written by adding
+// testGrant to the grants it already has, then putting the original
+// ACL back. A bucket whose ACL can't be read is left alone, since it
+// couldn't be restored.
+func (s *Scanner) writeBucketACP(ctx context.Context, client *s3.Client, bucket string) error {
+	original, err := client.GetBucketAcl(ctx, &s3.GetBucketAclInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		s.log.Debugf("Unable to read the ACL of %s to restore it, not testing whether it is writable", Printable(bucket))
+		return err
+	}
+	_, err = client.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+		Bucket: aws.String(bucket),
+		AccessControlPolicy: &types.AccessControlPolicy{
+			Owner:  original.Owner,
+			Grants: append(append([]types.Grant(nil), original.Grants...), testGrant),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// the original ACL is put back even once the scan has been cancelled
+	cleanupCtx, cancel := context.WithTimeout(withoutCancel{ctx}, cleanupTimeout)
+	defer cancel()
+	_, err = client.PutBucketAcl(cleanupCtx, &s3.PutBucketAclInput{
+		Bucket: aws.String(bucket),
+		AccessControlPolicy: &types.AccessControlPolicy{
+			Owner:  original.Owner,
+			Grants: original.Grants,
+		},
+	})
+	if err != nil {
+		s.log.Warnf("Failed to restore the ACL of %s, it still grants AuthenticatedUsers READ, %v", Printable(bucket), err)
+	} else {
+		s.log.Debugf("Restored the ACL of %s", Printable(bucket))
+	}
+	return nil
+}
+
+// writeObjectACP tests whether an object's ACL can be written the same way
+// as writeBucketACP, so a private object is never made public by the test
+func (s *Scanner) writeObjectACP(ctx context.Context, client *s3.Client, bucket string, key string) error {
+	original, err := client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.log.Debugf("Unable to read the ACL of %s/%s to restore it, not testing whether it is writable", Printable(bucket), Printable(key))
+		return err
+	}
+	_, err = client.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		AccessControlPolicy: &types.AccessControlPolicy{
+			Owner:  original.Owner,
+			Grants: append(append([]types.Grant(nil), original.Grants...), testGrant),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	cleanupCtx, cancel := context.WithTimeout(withoutCancel{ctx}, cleanupTimeout)
+	defer cancel()
+	_, err = client.PutObjectAcl(cleanupCtx, &s3.PutObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		AccessControlPolicy: &types.AccessControlPolicy{
+			Owner:  original.Owner,
+			Grants: original.Grants,
+		},
+	})
+	if err != nil {
+		s.log.Warnf("Failed to restore the ACL of %s/%s, it still grants AuthenticatedUsers READ, %v", Printable(bucket), Printable(key), err)
+	} else {
+		s.log.Debugf("Restored the ACL of %s/%s", Printable(bucket), Printable(key))
+	}
+	return nil
+}
+
+func (s *Scanner) putBucketACP(ctx context.Context, client *s3.Client, bucket string) bool {
+	if s.skipWrite("PutBucketAcl", Printable(bucket), fmt.Sprintf("Bucket=%s adds READ for %s, then restores the ACL", Printable(bucket), AuthenticatedUsersURI)) {
+		return false
+	}
+	s.log.Debugf("Attempting to write bucket ACP to %s", Printable(bucket))
+	if err := s.writeBucketACP(ctx, client, bucket); err != nil {
+		return false
+	}
+	s.report(Finding{
+		Bucket:   bucket,
+		Region:   client.Options().Region,
+		Kind:     KindWritableACP,
+		Severity: "HIGH",
+		Message:  fmt.Sprintf("Writable Bucket ACP in bucket %s", Printable(bucket)),
+	})
+	return true
+}
+
+func (s *Scanner) putObjectACP(ctx context.Context, client *s3.Client, bucket string, key string) {
+	if s.skipWrite("PutObjectAcl", Printable(bucket)+"/"+Printable(key), fmt.Sprintf("Bucket=%s Key=%s adds READ for %s, then restores the ACL", Printable(bucket), Printable(key), AuthenticatedUsersURI)) {
+		return
+	}
+	s.log.Debugf("Attempting to write object ACP to %s/%s", Printable(bucket), Printable(key))
+	if err := s.writeObjectACP(ctx, client, bucket, key); err != nil {
+		s.logFailure(err, "Failed to write object ACP to %s/%s", Printable(bucket), Printable(key))
+		return
+	}
+	s.report(Finding{
+		Bucket:   bucket,
+		Key:      key,
+		Region:   client.Options().Region,
+		Kind:     KindWritableObjectACP,
+		Severity: "HIGH",
+		Message:  fmt.Sprintf("Writable Bucket Object ACP %s/%s", Printable(bucket), Printable(key)),
+	})
+}
+
+// defaultMaxFindings is the number of objects with public access issues
+// reported per bucket when MaxFindings isn't set
+const defaultMaxFindings = 5
+
+func (s *Scanner) iterateBucket(ctx context.Context, client *s3.Client, bucket string, bucketACL *aclSummary) {
+	if bucketACL == nil && s.enabled(CheckACL) {
+		s.log.Infof("Bucket ACL unavailable for %s, skipping the object outlier check", Printable(bucket))
+	}
+
+	scanCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// a listing that fails partway leaves the rest of its objects unchecked
+	var listFailed atomic.Bool
+	// sampled is set once there are objects left past the Sample
+	var sampled atomic.Bool
+	list := func(prefix string, delimiter string, check func(key string) bool) []string {
+		prefixes, err := s.listPrefix(ctx, client, bucket, prefix, delimiter, check)
+		if err != nil && ctx.Err() == nil {
+			listFailed.Store(true)
+		}
+		return prefixes
+	}
+
+	// once MaxFindings issues are found, it's enough to stop and move on,
+	// or with CountPastCap to stop reporting objects and just count the rest
+	limit := int32(s.opts.MaxFindings)
+	capped := func(issues int32) bool {
+		return limit > 0 && issues >= limit
+	}
+	var issueCounter atomic.Int32
+	check := func(key string) bool {
+		// an object's findings are held until it has been counted, so that
+		// objects checked at once can't report more than MaxFindings issues
+		var found []Finding
+		collect := func(f Finding) {
+			found = append(found, f)
+		}
+		if !s.checkObjectACL(ctx, client, bucket, key, bucketACL, collect) {
+			if !capped(issueCounter.Load()) {
+				s.reportAll(found)
+			}
+			return true
+		}
+		issues := issueCounter.Add(1)
+		if !capped(issues - 1) {
+			s.reportAll(found)
+		}
+		if issues == limit && !s.opts.CountPastCap {
+			s.log.Debugf("Found %d objects with public access issues in %s, skipping the rest.", limit, Printable(bucket))
+			cancel()
+		}
+		return !capped(issues) || s.opts.CountPastCap
+	}
+	defer func() {
+		if issues := issueCounter.Load(); capped(issues) && issues > limit {
+			s.report(Finding{
+				Bucket:   bucket,
+				Region:   client.Options().Region,
+				Kind:     KindObjectIssueCount,
+				Severity: "INFORMATIONAL",
+				Message:  fmt.Sprintf("Reported %d of %d objects with public access issues in %s", limit, issues, Printable(bucket)),
+			})
+		}
+
+		var reasons []string
+		if errors.Is(scanCtx.Err(), context.DeadlineExceeded) {
+			reasons = append(reasons, "the bucket timed out")
+		} else if scanCtx.Err() != nil {
+			reasons = append(reasons, "the scan was cancelled")
+		} else if capped(issueCounter.Load()) && !s.opts.CountPastCap {
+			reasons = append(reasons, fmt.Sprintf("stopped after %d objects with public access issues", limit))
+		} else if sampled.Load() {
+			reasons = append(reasons, fmt.Sprintf("sampled the first %d objects", s.opts.Sample))
+		}
+		if listFailed.Load() {
+			reasons = append(reasons, "listing the objects failed")
+		}
+		if len(reasons) > 0 {
+			reason := strings.Join(reasons, ", ")
+			s.report(Finding{
+				Bucket:     bucket,
+				Region:     client.Options().Region,
+				Kind:       KindScanIncomplete,
+				Severity:   "INFORMATIONAL",
+				Message:    fmt.Sprintf("Not every object in %s was checked, %s", Printable(bucket), reason),
+				Incomplete: true,
+				Reason:     reason,
+			})
+		}
+	}()
+
+	// objects are handed to a pool of checkers as they are listed, and
+	// listing stops once the scan is cancelled
+	if s.opts.ObjectConcurrency > 1 {
+		objects := make(chan string)
+		var checkers sync.WaitGroup
+		for i := 0; i < s.opts.ObjectConcurrency; i++ {
+			checkers.Add(1)
+			go func(check func(key string) bool) {
+				defer checkers.Done()
+				for key := range objects {
+					if ctx.Err() == nil {
+						check(key)
+					}
+				}
+			}(check)
+		}
+		defer func() {
+			close(objects)
+			checkers.Wait()
+		}()
+		check = func(key string) bool {
+			select {
+			case objects <- key:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+
+	// the sample stops the listings rather than cancelling, so objects
+	// already handed out are still checked
+	if s.opts.Sample > 0 {
+		var examined atomic.Int64
+		unsampled := check
+		check = func(key string) bool {
+			if examined.Add(1) > int64(s.opts.Sample) {
+				sampled.Store(true)
+				return false
+			}
+			return unsampled(key)
+		}
+	}
+
+	if len(s.opts.SkipPrefixes) > 0 {
+		unskipped := check
+		check = func(key string) bool {
+			return s.skipped(key) || unskipped(key)
+		}
+	}
+
+	if s.opts.Fanout <= 1 && len(s.opts.SkipPrefixes) == 0 {
+		list(s.opts.Prefix, "", check)
+		return
+	}
+
+	// list the top level first, then page through each top-level prefix in
+	// parallel since pagination within one listing is serial
+	prefixes := s.expandPrefix(bucket, s.opts.Prefix, list, check)
+
+	workers := s.opts.Fanout
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	prefixChan := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for prefix := range prefixChan {
+				list(prefix, "", check)
+			}
+		}()
+	}
+
+	for _, prefix := range prefixes {
+		if ctx.Err() != nil || sampled.Load() {
+			break
+		}
+		prefixChan <- prefix
+	}
+	close(prefixChan)
+	wg.Wait()
+}
+
+// skipped reports whether key, or every key under a common prefix, falls
+// under one of the SkipPrefixes
+func (s *Scanner) skipped(key string) bool {
+	for _, skip := range s.opts.SkipPrefixes {
+		if strings.HasPrefix(key, skip) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandPrefix lists one level of prefix, checking the objects at that
+// level, and returns the common prefixes beneath it that can be listed in
+// full. Skipped prefixes are left out without being listed, and a prefix
+// with a skipped prefix somewhere below it is expanded in turn.
+func (s *Scanner) expandPrefix(bucket string, prefix string, list func(prefix string, delimiter string, check func(key string) bool) []string, check func(key string) bool) []string {
+	var full []string
+	for _, common := range list(prefix, "/", check) {
+		if s.skipped(common) {
+			s.log.Debugf("Skipping %s/%s", Printable(bucket), Printable(common))
+			continue
+		}
+		nested := false
+		for _, skip := range s.opts.SkipPrefixes {
+			if strings.HasPrefix(skip, common) {
+				nested = true
+			}
+		}
+		if nested {
+			full = append(full, s.expandPrefix(bucket, common, list, check)...)
+		} else {
+			full = append(full, common)
+		}
+	}
+	return full
+}
+
+// listPrefix pages through the objects under prefix, passing each key to
+// check until it returns false, and returns any common prefixes found when
+// a delimiter is given, along with any error that ended the listing early
+func (s *Scanner) listPrefix(ctx context.Context, client *s3.Client, bucket string, prefix string, delimiter string, check func(key string) bool) ([]string, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+	}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+	if delimiter != "" {
+		input.Delimiter = aws.String(delimiter)
+	}
+	paginator := s3.NewListObjectsV2Paginator(client, input)
+
+	var prefixes []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logFailure(err, "Failed to iterate page in bucket %s", Printable(bucket))
+			}
+			return prefixes, err
+		}
+
+		for _, commonPrefix := range page.CommonPrefixes {
+			prefixes = append(prefixes, aws.ToString(commonPrefix.Prefix))
+		}
+		for _, object := range page.Contents {
+			if !check(*object.Key) {
+				return prefixes, nil
+			}
+		}
+	}
+	return prefixes, nil
+}
+
+// checkKeys checks a known list of keys without listing the bucket, so it
+// also works when listing is denied
+func (s *Scanner) checkKeys(ctx context.Context, client *s3.Client, bucket string, keys []string, bucketACL *aclSummary) {
+	for _, key := range keys {
+		head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			s.logFailure(err, "Failed to read object %s/%s", Printable(bucket), Printable(key))
+			continue
+		}
+		objectType := aws.ToString(head.ContentType)
+		if objectType == "" {
+			objectType = contentType(key)
+		}
+		s.report(Finding{
+			Bucket:      bucket,
+			Key:         key,
+			Region:      client.Options().Region,
+			Kind:        KindObjectReadable,
+			Severity:    "MEDIUM",
+			Message:     fmt.Sprintf("Readable object found: %s/%s", Printable(bucket), Printable(key)),
+			ContentType: Printable(objectType),
+		})
+
+		s.checkObjectACL(ctx, client, bucket, key, bucketACL, s.report)
+	}
+}
+
+// checkObjectACL passes public grants on a single object to emit and returns
+// true when the object counts as an issue toward MaxFindings: an outlier, a
+// publicly writable object, or with CountPublicRead a publicly readable one
+func (s *Scanner) checkObjectACL(ctx context.Context, client *s3.Client, bucket string, key string, bucketACL *aclSummary, emit func(Finding)) bool {
+	if s.opts.Aggressive {
+		s.putObjectACP(ctx, client, bucket, key)
+	}
+	s.log.Debugf("Checking ACP on %s/%s", Printable(bucket), Printable(key))
+	s.objectsChecked.Add(1)
+
+	// Get the ACL for each object
+	aclOutput, err := client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.logFailure(err, "Failed to get ACL for object %s/%s", Printable(bucket), Printable(key))
+		return false
+	}
+
+	// Check if the ACL includes permissions by unauthorized users
+	objectACL := summarizeGrants(aclOutput.Grants)
+
+	// a public object in a bucket that doesn't grant the same access
+	// is unusual, so it is reported as an outlier instead of a plain
+	// public object
+	if bucketACL != nil && isOutlier(objectACL, *bucketACL) {
+		permission := objectACL.readPermission
+		if objectACL.publicWrite {
+			permission = objectACL.writePermission
+		}
+		emit(Finding{
+			Bucket:   bucket,
+			Key:      key,
+			Region:   client.Options().Region,
+			Kind:     KindObjectACLOutlier,
+			Severity: outlierSeverity(*bucketACL),
+			Message:  fmt.Sprintf("Object ACL more permissive than bucket ACL: %s/%s", Printable(bucket), Printable(key)),
+
+			Grantee:     AllUsersURI,
+			Permission:  string(permission),
+			Source:      "acl",
+			Scope:       objectScope(bucketACL),
+			ContentType: contentType(key),
+		})
+		return true
+	}
+
+	// Decide what to print based on the flags
+	if objectACL.publicWrite {
+		emit(Finding{
+			Bucket:   bucket,
+			Key:      key,
+			Region:   client.Options().Region,
+			Kind:     KindObjectPublicWrite,
+			Severity: "HIGH",
+			Message:  fmt.Sprintf("Object with public write access found: %s/%s", Printable(bucket), Printable(key)),
+
+			Grantee:     AllUsersURI,
+			Permission:  string(objectACL.writePermission),
+			Source:      "acl",
+			Scope:       objectScope(bucketACL),
+			ContentType: contentType(key),
+		})
+	}
+
+	if objectACL.publicRead {
+		emit(Finding{
+			Bucket:   bucket,
+			Key:      key,
+			Region:   client.Options().Region,
+			Kind:     KindObjectPublicRead,
+			Severity: "MEDIUM",
+			Message:  fmt.Sprintf("Object with public read access found: %s/%s", Printable(bucket), Printable(key)),
+
+			Grantee:     AllUsersURI,
+			Permission:  string(objectACL.readPermission),
+			Source:      "acl",
+			Scope:       objectScope(bucketACL),
+			ContentType: contentType(key),
+		})
+	}
+
+	for _, permission := range objectACL.authenticated {
+		emit(Finding{
+			Bucket:   bucket,
+			Key:      key,
+			Region:   client.Options().Region,
+			Kind:     KindObjectAuthenticatedUsers,
+			Severity: "MEDIUM",
+			Message:  fmt.Sprintf("Object with %s access for any AWS account found: %s/%s", permission, Printable(bucket), Printable(key)),
+
+			Grantee:     AuthenticatedUsersURI,
+			Permission:  string(permission),
+			Source:      "acl",
+			Scope:       objectScope(bucketACL),
+			ContentType: contentType(key),
+		})
+	}
+
+	return objectACL.publicWrite || (s.opts.CountPublicRead && objectACL.publicRead)
+}
@@ -0,0 +1,337 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func groupGrant(uri string, permission types.Permission) types.Grant {
+	return types.Grant{
+		Grantee:    &types.Grantee{Type: types.TypeGroup, URI: aws.String(uri)},
+		Permission: permission,
+	}
+}
+
+const allUsers = AllUsersURI
+
+func TestSummarizeGrants(t *testing.T) {
+	owner := types.Grant{
+		Grantee:    &types.Grantee{Type: types.TypeCanonicalUser, ID: aws.String("owner")},
+		Permission: types.PermissionFullControl,
+	}
+
+	tests := []struct {
+		name   string
+		grants []types.Grant
+		want   aclSummary
+	}{
+		{"no grants", nil, aclSummary{}},
+		{"owner only", []types.Grant{owner}, aclSummary{}},
+		{"public read", []types.Grant{owner, groupGrant(allUsers, types.PermissionRead)}, aclSummary{publicRead: true, readPermission: types.PermissionRead}},
+		{"public write", []types.Grant{groupGrant(allUsers, types.PermissionWrite)}, aclSummary{publicWrite: true, writePermission: types.PermissionWrite}},
+		{"full control counts as write", []types.Grant{groupGrant(allUsers, types.PermissionFullControl)}, aclSummary{publicWrite: true, writePermission: types.PermissionFullControl}},
+		{"read and write", []types.Grant{groupGrant(allUsers, types.PermissionRead), groupGrant(allUsers, types.PermissionWrite)}, aclSummary{publicRead: true, publicWrite: true, readPermission: types.PermissionRead, writePermission: types.PermissionWrite}},
+		{"other group ignored", []types.Grant{groupGrant("http://acs.amazonaws.com/groups/s3/LogDelivery", types.PermissionWrite)}, aclSummary{}},
+		{"authenticated users", []types.Grant{groupGrant(AuthenticatedUsersURI, types.PermissionRead), groupGrant(AuthenticatedUsersURI, types.PermissionWrite)}, aclSummary{authenticated: []types.Permission{types.PermissionRead, types.PermissionWrite}}},
+		{"authenticated users ACP ignored", []types.Grant{groupGrant(AuthenticatedUsersURI, types.PermissionReadAcp)}, aclSummary{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizeGrants(tt.grants); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarizeGrants() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsOutlier(t *testing.T) {
+	private := aclSummary{}
+	read := aclSummary{publicRead: true}
+	write := aclSummary{publicWrite: true}
+	both := aclSummary{publicRead: true, publicWrite: true}
+
+	tests := []struct {
+		name     string
+		object   aclSummary
+		bucket   aclSummary
+		want     bool
+		severity string
+	}{
+		{"private object in private bucket", private, private, false, "HIGH"},
+		{"public object in private bucket", read, private, true, "HIGH"},
+		{"writable object in private bucket", write, private, true, "HIGH"},
+		{"public object in public bucket", read, read, false, "MEDIUM"},
+		{"writable object in readable bucket", write, read, true, "MEDIUM"},
+		{"public object in open bucket", both, both, false, "MEDIUM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOutlier(tt.object, tt.bucket); got != tt.want {
+				t.Errorf("isOutlier() = %v, want %v", got, tt.want)
+			}
+			if got := outlierSeverity(tt.bucket); got != tt.severity {
+				t.Errorf("outlierSeverity() = %q, want %q", got, tt.severity)
+			}
+		})
+	}
+}
+
+func TestObjectScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket *aclSummary
+		want   string
+	}{
+		{"unknown bucket ACL", nil, ""},
+		{"private bucket", &aclSummary{}, "public object in private bucket"},
+		{"readable bucket", &aclSummary{publicRead: true}, "public object in public bucket"},
+		{"writable bucket", &aclSummary{publicWrite: true}, "public object in public bucket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := objectScope(tt.bucket); got != tt.want {
+				t.Errorf("objectScope() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestUploadCleansUp(t *testing.T) {
+	for _, noCleanup := range []bool{false, true} {
+		fake := &fakeS3{}
+		client := newFakeClient(t, fake)
+
+		s, wait := newTestScanner(t, Options{NoCleanup: noCleanup})
+		uploaded := s.testUpload(context.Background(), client, "bucket", "s3-warden-test.txt", strings.NewReader("s3-warden-test"))
+		findings := wait()
+
+		if !uploaded || len(findings) != 1 || findings[0].Kind != "upload-allowed" {
+			t.Fatalf("no cleanup %v: uploaded = %v, findings = %+v, want one upload-allowed", noCleanup, uploaded, findings)
+		}
+		var want []string
+		if !noCleanup {
+			want = []string{"s3-warden-test.txt"}
+		}
+		if !reflect.DeepEqual(fake.deleted, want) {
+			t.Errorf("no cleanup %v: deleted %v, want %v", noCleanup, fake.deleted, want)
+		}
+	}
+}
+
+func TestWithoutCancel(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "value"), time.Hour)
+	cancel()
+
+	detached := withoutCancel{ctx}
+	if detached.Err() != nil || detached.Done() != nil {
+		t.Errorf("Err() = %v, want the cancellation dropped", detached.Err())
+	}
+	if _, ok := detached.Deadline(); ok {
+		t.Error("Deadline() kept the parent's deadline")
+	}
+	if got := detached.Value(key{}); got != "value" {
+		t.Errorf("Value() = %v, want the parent's value", got)
+	}
+}
+
+// recordingLogger keeps the warnings logged, to check what a scan said
+type recordingLogger struct {
+	nopLogger
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (l *recordingLogger) Warnf(format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, fmt.Sprintf(format, a...))
+}
+
+func TestDryRunSkipsWrites(t *testing.T) {
+	fake := &fakeS3{keys: []string{"a"}}
+	client := newFakeClient(t, fake)
+	logger := &recordingLogger{}
+
+	s, wait := newTestScanner(t, Options{Aggressive: true, DryRun: true, Logger: logger})
+	uploaded := s.testUpload(context.Background(), client, "bucket", "s3-warden-test.txt", strings.NewReader("s3-warden-test"))
+	acpWritable := s.putBucketACP(context.Background(), client, "bucket")
+	s.putObjectACP(context.Background(), client, "bucket", "a")
+	findings := wait()
+
+	if uploaded || acpWritable || len(findings) != 0 || len(fake.uploaded) != 0 {
+		t.Errorf("dry run wrote to the bucket: uploaded %v, ACP %v, findings %+v", uploaded, acpWritable, findings)
+	}
+	want := []string{
+		"Dry run, not calling PutObject on bucket/s3-warden-test.txt",
+		"Dry run, not calling PutBucketAcl on bucket",
+		"Dry run, not calling PutObjectAcl on bucket/a",
+	}
+	if !reflect.DeepEqual(logger.warnings, want) {
+		t.Errorf("warnings = %q, want %q", logger.warnings, want)
+	}
+}
+
+func TestPutBucketACPRestoresACL(t *testing.T) {
+	fake := &fakeS3{}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{Aggressive: true})
+	writable := s.putBucketACP(context.Background(), client, "bucket")
+	findings := wait()
+
+	if !writable || len(findings) != 1 || findings[0].Kind != "writable-acp" {
+		t.Fatalf("writable = %v, findings = %+v, want one writable-acp", writable, findings)
+	}
+	if len(fake.aclPuts) != 2 {
+		t.Fatalf("wrote %d ACLs, want the test ACL and the original", len(fake.aclPuts))
+	}
+	test, restored := fake.aclPuts[0], fake.aclPuts[1]
+	// the owner's grant is kept alongside the test grant, and the restored
+	// ACL has only the owner's
+	if !strings.Contains(test, "<ID>owner</ID>") || !strings.Contains(test, AuthenticatedUsersURI) {
+		t.Errorf("test ACL = %s, want the owner's grant and the test grant", test)
+	}
+	if !strings.Contains(restored, "<ID>owner</ID>") || strings.Contains(restored, AuthenticatedUsersURI) {
+		t.Errorf("restored ACL = %s, want only the owner's grant", restored)
+	}
+}
+
+func TestPutBucketACPSkipsUnreadableACL(t *testing.T) {
+	fake := &fakeS3{denied: true}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{Aggressive: true})
+	writable := s.putBucketACP(context.Background(), client, "bucket")
+	wait()
+
+	if writable || len(fake.aclPuts) != 0 {
+		t.Errorf("writable = %v after %d ACL writes, want an unreadable ACL left alone", writable, len(fake.aclPuts))
+	}
+}
+
+func TestPutObjectACPRestoresACL(t *testing.T) {
+	fake := &fakeS3{keys: []string{"private.txt"}}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{Aggressive: true})
+	s.putObjectACP(context.Background(), client, "bucket", "private.txt")
+	findings := wait()
+
+	if len(findings) != 1 || findings[0].Kind != "writable-object-acp" {
+		t.Fatalf("findings = %+v, want one writable-object-acp", findings)
+	}
+	if len(fake.aclPuts) != 2 {
+		t.Fatalf("wrote %d ACLs, want the test ACL and the original", len(fake.aclPuts))
+	}
+	test, restored := fake.aclPuts[0], fake.aclPuts[1]
+	if strings.Contains(test, AllUsersURI) || !strings.Contains(test, AuthenticatedUsersURI) || !strings.Contains(test, "<ID>owner</ID>") {
+		t.Errorf("test ACL = %s, want the owner's grant and READ for AWS users, not everyone", test)
+	}
+	if strings.Contains(restored, AuthenticatedUsersURI) || !strings.Contains(restored, "<ID>owner</ID>") {
+		t.Errorf("restored ACL = %s, want only the owner's grant", restored)
+	}
+}
+
+func TestCheckBucketACL(t *testing.T) {
+	tests := []struct {
+		name             string
+		public, writable bool
+		want             []string
+	}{
+		{"private", false, false, nil},
+		{"public read", true, false, []string{"public-read"}},
+		{"public write", false, true, []string{"public-write"}},
+		{"both", true, true, []string{"public-write", "public-read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(t, &fakeS3{publicBucket: tt.public, writableBucket: tt.writable})
+
+			s, wait := newTestScanner(t, Options{})
+			summary, err := s.checkBucketACL(context.Background(), client, "bucket")
+			findings := wait()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var kinds []string
+			for _, f := range findings {
+				kinds = append(kinds, f.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.want) {
+				t.Errorf("findings = %q, want %q", kinds, tt.want)
+			}
+			if summary.publicRead != tt.public || summary.publicWrite != tt.writable {
+				t.Errorf("summary = %+v, want publicRead %v and publicWrite %v", summary, tt.public, tt.writable)
+			}
+		})
+	}
+}
+
+func TestCheckObjectACL(t *testing.T) {
+	tests := []struct {
+		name             string
+		public, writable bool
+		want             []string
+		issue            bool
+	}{
+		{"private", false, false, nil, false},
+		{"public read", true, false, []string{"object-public-read"}, false},
+		{"public write", false, true, []string{"object-public-write"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeS3{
+				keys:         []string{"a"},
+				publicKeys:   map[string]bool{"a": tt.public},
+				writableKeys: map[string]bool{"a": tt.writable},
+			}
+			client := newFakeClient(t, fake)
+
+			// with no bucket ACL to compare against, nothing is an outlier
+			s, wait := newTestScanner(t, Options{})
+			var kinds []string
+			issue := s.checkObjectACL(context.Background(), client, "bucket", "a", nil, func(f Finding) {
+				kinds = append(kinds, f.Kind)
+			})
+			wait()
+
+			if !reflect.DeepEqual(kinds, tt.want) || issue != tt.issue {
+				t.Errorf("findings = %q, issue = %v, want %q and %v", kinds, issue, tt.want, tt.issue)
+			}
+		})
+	}
+}
+
+func TestCheckBucketACLAuthenticatedUsers(t *testing.T) {
+	fake := &fakeS3{bucketGrants: []string{
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>` + AuthenticatedUsersURI + `</URI></Grantee><Permission>FULL_CONTROL</Permission></Grant>`,
+	}}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{})
+	if _, err := s.checkBucketACL(context.Background(), client, "bucket"); err != nil {
+		t.Fatal(err)
+	}
+	findings := wait()
+
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want one authenticated-users", findings)
+	}
+	f := findings[0]
+	if f.Kind != KindAuthenticatedUsers || f.Severity != "MEDIUM" || f.Grantee != AuthenticatedUsersURI || f.Permission != "FULL_CONTROL" {
+		t.Errorf("finding = %+v, want a MEDIUM authenticated-users finding for FULL_CONTROL", f)
+	}
+}
@@ -0,0 +1,66 @@
+package warden
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The checks Options.Checks can select, each run on every bucket. The
+// writes made by Aggressive aren't among them, as they have their own
+// option.
+const (
+	CheckACL               = "acl"
+	CheckListing           = "listing"
+	CheckPolicy            = "policy"
+	CheckEncryption        = "encryption"
+	CheckPublicAccessBlock = "public-access-block"
+	CheckVersioning        = "versioning"
+	CheckWebsite           = "website"
+	CheckObjects           = "objects"
+)
+
+// Checks lists every check, in the order they run
+var Checks = []string{
+	CheckACL,
+	CheckListing,
+	CheckPolicy,
+	CheckEncryption,
+	CheckPublicAccessBlock,
+	CheckVersioning,
+	CheckWebsite,
+	CheckObjects,
+}
+
+// checkSet returns the checks named, or nil to run all of them when none
+// are named or one is "all"
+func checkSet(names []string) (map[string]bool, error) {
+	set := map[string]bool{}
+	for _, name := range names {
+		if name == "all" {
+			return nil, nil
+		}
+		if !IsCheck(name) {
+			return nil, fmt.Errorf("unknown check %q, use one of %s", name, strings.Join(Checks, ", "))
+		}
+		set[name] = true
+	}
+	if len(set) == 0 {
+		return nil, nil
+	}
+	return set, nil
+}
+
+// IsCheck reports whether name is one of the Checks
+func IsCheck(name string) bool {
+	for _, check := range Checks {
+		if check == name {
+			return true
+		}
+	}
+	return false
+}
+
+// enabled reports whether the check is run on each bucket
+func (s *Scanner) enabled(check string) bool {
+	return s.checks == nil || s.checks[check]
+}
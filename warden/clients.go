@@ -0,0 +1,52 @@
+package warden
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// clientFor returns the S3 client for cfg's region, creating it on first
+// use so that buckets in the same region share one client and its
+// connection pool. Clients are pointed at Endpoint when one is set.
+func (s *Scanner) clientFor(cfg aws.Config) *s3.Client {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	client, ok := s.clients[cfg.Region]
+	if !ok {
+		client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+			if s.opts.Endpoint != "" {
+				o.BaseEndpoint = aws.String(s.opts.Endpoint)
+			}
+			o.UsePathStyle = s.opts.PathStyle
+		})
+		s.clients[cfg.Region] = client
+	}
+	return client
+}
+
+// BucketClient returns a client for bucket set up the way the scan's are:
+// pointed at Endpoint when one is set, in the fixed Region when there is
+// one, and otherwise in the region the bucket is looked up in
+func (s *Scanner) BucketClient(ctx context.Context, bucket string) (*s3.Client, error) {
+	cfg, err := s.LoadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case s.opts.Endpoint != "":
+		if cfg.Region == "" {
+			cfg.Region = "us-east-1"
+		}
+	case s.opts.Region != "":
+		cfg.Region = s.opts.Region
+	default:
+		if cfg.Region, err = s.lookupRegion(ctx, bucket); err != nil {
+			return nil, err
+		}
+	}
+	return s.clientFor(cfg), nil
+}
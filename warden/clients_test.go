@@ -0,0 +1,62 @@
+package warden
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestClientForReusesClientsPerRegion(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	east := s.clientFor(aws.Config{Region: "us-east-1"})
+	if again := s.clientFor(aws.Config{Region: "us-east-1"}); again != east {
+		t.Error("second client for us-east-1 is a new client, want the cached one")
+	}
+	west := s.clientFor(aws.Config{Region: "eu-west-1"})
+	if west == east {
+		t.Error("eu-west-1 shares the us-east-1 client")
+	}
+	if got := west.Options().Region; got != "eu-west-1" {
+		t.Errorf("eu-west-1 client region = %q", got)
+	}
+}
+
+func TestBucketClientUsesEndpoint(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	fake := &fakeS3{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	// with an endpoint there is no region lookup, which would go to AWS
+	s, err := New(Options{Endpoint: server.URL, PathStyle: true, Anonymous: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := s.BucketClient(context.Background(), "reports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String("reports"),
+		Key:    aws.String("scan.json"),
+		Body:   strings.NewReader("{}"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.uploaded) != 1 || fake.uploaded[0] != "scan.json" {
+		t.Errorf("uploaded %q to the endpoint, want scan.json", fake.uploaded)
+	}
+}
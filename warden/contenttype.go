@@ -0,0 +1,18 @@
+package warden
+
+import (
+	"mime"
+	"path"
+	"strings"
+)
+
+// contentType guesses an object's content type from its key's extension,
+// which costs no request, or returns "" when the extension isn't known
+func contentType(key string) string {
+	ext := path.Ext(key)
+	if ext == "" {
+		return ""
+	}
+	mediaType, _, _ := strings.Cut(mime.TypeByExtension(strings.ToLower(ext)), ";")
+	return mediaType
+}
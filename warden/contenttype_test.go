@@ -0,0 +1,36 @@
+package warden
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"images/logo.png", "image/png"},
+		{"data/export.JSON", "application/json"},
+		{"notes.txt", "text/plain"},
+		{"README", ""},
+		{"config/.unknown-ext-s3warden", ""},
+	}
+
+	for _, tt := range tests {
+		if got := contentType(tt.key); got != tt.want {
+			t.Errorf("contentType(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestCheckObjectACLContentType(t *testing.T) {
+	client := newFakeClient(t, &fakeS3{keys: []string{"photo.png"}, publicKeys: map[string]bool{"photo.png": true}})
+	s, wait := newTestScanner(t, Options{})
+	s.checkObjectACL(context.Background(), client, "bucket", "photo.png", nil, s.report)
+	findings := wait()
+
+	if len(findings) != 1 || findings[0].ContentType != "image/png" {
+		t.Errorf("findings = %+v, want one with content type image/png", findings)
+	}
+}
@@ -0,0 +1,57 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// roleSessionName names the sessions of an assumed role, so the scan can
+// be picked out in the target account's CloudTrail
+const roleSessionName = "s3-warden"
+
+// applyCredentials makes cfg send unsigned requests when Anonymous is set
+// or no credentials can be found, since signed requests without usable
+// credentials fail on buckets that anyone could read. With RoleARN the
+// credentials found are used to assume the role, and a role that can't be
+// assumed is an error rather than a reason to go anonymous.
+func (s *Scanner) applyCredentials(ctx context.Context, cfg *aws.Config) error {
+	if s.opts.RoleARN != "" {
+		return s.assumeRole(ctx, cfg)
+	}
+	if !s.opts.Anonymous {
+		if cfg.Credentials != nil {
+			if _, err := cfg.Credentials.Retrieve(ctx); err == nil {
+				return nil
+			}
+		}
+		s.log.Warnf("No AWS credentials found, making anonymous requests")
+	}
+	cfg.Credentials = aws.AnonymousCredentials{}
+	return nil
+}
+
+// assumeRole replaces cfg's credentials with those of RoleARN, refreshed as
+// they expire, checking up front that the role can be assumed
+func (s *Scanner) assumeRole(ctx context.Context, cfg *aws.Config) error {
+	client := sts.NewFromConfig(*cfg, func(o *sts.Options) {
+		// STS has a global endpoint, so no region needs to be configured
+		if o.Region == "" {
+			o.Region = "us-east-1"
+		}
+	})
+	provider := stscreds.NewAssumeRoleProvider(client, s.opts.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = roleSessionName
+		if s.opts.ExternalID != "" {
+			o.ExternalID = aws.String(s.opts.ExternalID)
+		}
+	})
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		return fmt.Errorf("unable to assume role %s, %v", s.opts.RoleARN, err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestLoadConfigFallsBackToAnonymous(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := s.LoadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.Credentials.(aws.AnonymousCredentials); !ok {
+		t.Errorf("Credentials = %T, want anonymous credentials", cfg.Credentials)
+	}
+}
+
+func TestLoadConfigAnonymousOverridesCredentials(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	for _, anonymous := range []bool{false, true} {
+		s, err := New(Options{Anonymous: anonymous})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := s.LoadConfig(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := cfg.Credentials.(aws.AnonymousCredentials); ok != anonymous {
+			t.Errorf("Anonymous %v: credentials = %T", anonymous, cfg.Credentials)
+		}
+	}
+}
+
+func TestLoadConfigAssumesRole(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		if form.Get("RoleArn") == "arn:aws:iam::111122223333:role/denied" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>not authorized</Message></Error></ErrorResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult><Credentials>`+
+			`<AccessKeyId>ASIAROLE</AccessKeyId><SecretAccessKey>role-secret</SecretAccessKey><SessionToken>token</SessionToken>`+
+			`<Expiration>2099-01-01T00:00:00Z</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`)
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	s, err := New(Options{RoleARN: "arn:aws:iam::111122223333:role/audit", ExternalID: "ext-123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := s.LoadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "ASIAROLE" {
+		t.Errorf("AccessKeyID = %q, want the assumed role's", creds.AccessKeyID)
+	}
+	if form.Get("ExternalId") != "ext-123" || form.Get("RoleSessionName") != roleSessionName {
+		t.Errorf("AssumeRole form = %v, want the external ID and session name", form)
+	}
+
+	s, err = New(Options{RoleARN: "arn:aws:iam::111122223333:role/denied"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadConfig(context.Background()); err == nil {
+		t.Error("LoadConfig succeeded when the role couldn't be assumed")
+	}
+}
+
+func TestNewRejectsRoleOptions(t *testing.T) {
+	if _, err := New(Options{RoleARN: "arn:aws:iam::111122223333:role/audit", Anonymous: true}); err == nil {
+		t.Error("New accepted a role with anonymous requests")
+	}
+	if _, err := New(Options{ExternalID: "ext-123"}); err == nil {
+		t.Error("New accepted an external ID without a role")
+	}
+}
+
+func TestLoadConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	if err := os.WriteFile(configFile, []byte("[profile audit]\nregion = eu-central-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	credentialsFile := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(credentialsFile, []byte("[audit]\naws_access_key_id = AKIAAUDIT\naws_secret_access_key = secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+
+	s, err := New(Options{Profile: "audit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := s.LoadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Region != "eu-central-1" || creds.AccessKeyID != "AKIAAUDIT" {
+		t.Errorf("region %q and key %q, want the audit profile's", cfg.Region, creds.AccessKeyID)
+	}
+
+	s, err = New(Options{Profile: "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadConfig(context.Background()); err == nil || !strings.Contains(err.Error(), `profile "missing" not found`) {
+		t.Errorf("LoadConfig() error = %v, want the missing profile named", err)
+	}
+}
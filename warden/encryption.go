@@ -0,0 +1,104 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// encryptionNames are the usual names of the default encryption algorithms
+var encryptionNames = map[types.ServerSideEncryption]string{
+	types.ServerSideEncryptionAes256:     "SSE-S3",
+	types.ServerSideEncryptionAwsKms:     "SSE-KMS",
+	types.ServerSideEncryptionAwsKmsDsse: "DSSE-KMS",
+}
+
+// encryptionAlgorithms names the algorithms the default encryption rules
+// apply, once each
+func encryptionAlgorithms(rules []types.ServerSideEncryptionRule) []string {
+	var names []string
+	for _, rule := range rules {
+		if rule.ApplyServerSideEncryptionByDefault == nil {
+			continue
+		}
+		algorithm := rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm
+		name, ok := encryptionNames[algorithm]
+		if !ok {
+			name = string(algorithm)
+		}
+		names = appendNew(names, name)
+	}
+	return names
+}
+
+// kmsWithoutBucketKey reports whether any default encryption rule uses
+// SSE-KMS without S3 Bucket Keys, which makes a KMS request per object
+func kmsWithoutBucketKey(rules []types.ServerSideEncryptionRule) bool {
+	for _, rule := range rules {
+		if rule.ApplyServerSideEncryptionByDefault == nil {
+			continue
+		}
+		switch rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm {
+		case types.ServerSideEncryptionAwsKms, types.ServerSideEncryptionAwsKmsDsse:
+			if !aws.ToBool(rule.BucketKeyEnabled) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkBucketEncryption reports SSE-KMS default encryption with bucket keys
+// disabled, and with ReportEncryption which algorithm the bucket encrypts
+// new objects with, if any
+func (s *Scanner) checkBucketEncryption(ctx context.Context, client *s3.Client, bucket string) {
+	output, err := client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{
+		Bucket: aws.String(bucket),
+	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ServerSideEncryptionConfigurationNotFoundError" {
+		if s.opts.ReportEncryption {
+			s.report(Finding{
+				Bucket:   bucket,
+				Region:   client.Options().Region,
+				Kind:     KindEncryptionMissing,
+				Severity: "LOW",
+				Message:  fmt.Sprintf("No default encryption on bucket %s", Printable(bucket)),
+			})
+		}
+		return
+	}
+	if err != nil {
+		s.logFailure(err, "Failed to get the default encryption for %s", Printable(bucket))
+		return
+	}
+	if output.ServerSideEncryptionConfiguration == nil {
+		return
+	}
+
+	if algorithms := encryptionAlgorithms(output.ServerSideEncryptionConfiguration.Rules); s.opts.ReportEncryption && len(algorithms) > 0 {
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindDefaultEncryption,
+			Severity: "INFORMATIONAL",
+			Message:  fmt.Sprintf("Bucket %s encrypts new objects with %s by default", Printable(bucket), strings.Join(algorithms, ", ")),
+		})
+	}
+
+	if kmsWithoutBucketKey(output.ServerSideEncryptionConfiguration.Rules) {
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindKMSBucketKeyDisabled,
+			Severity: "LOW",
+			Message:  fmt.Sprintf("SSE-KMS default encryption without an S3 Bucket Key in %s", Printable(bucket)),
+		})
+	}
+}
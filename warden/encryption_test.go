@@ -0,0 +1,91 @@
+package warden
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func encryptionRule(algorithm types.ServerSideEncryption, bucketKey *bool) types.ServerSideEncryptionRule {
+	return types.ServerSideEncryptionRule{
+		ApplyServerSideEncryptionByDefault: &types.ServerSideEncryptionByDefault{SSEAlgorithm: algorithm},
+		BucketKeyEnabled:                   bucketKey,
+	}
+}
+
+func TestEncryptionAlgorithms(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []types.ServerSideEncryptionRule
+		want  []string
+	}{
+		{"no rules", nil, nil},
+		{"SSE-S3", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAes256, nil)}, []string{"SSE-S3"}},
+		{"SSE-KMS", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAwsKms, aws.Bool(true))}, []string{"SSE-KMS"}},
+		{"DSSE-KMS", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAwsKmsDsse, nil)}, []string{"DSSE-KMS"}},
+		{"repeated", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAes256, nil), encryptionRule(types.ServerSideEncryptionAes256, nil)}, []string{"SSE-S3"}},
+		{"rule without default", []types.ServerSideEncryptionRule{{}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encryptionAlgorithms(tt.rules); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("encryptionAlgorithms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBucketEncryption(t *testing.T) {
+	tests := []struct {
+		encryption string
+		report     bool
+		want       []string
+	}{
+		{encryption: "", report: true, want: []string{"encryption-missing"}},
+		{encryption: "aws:kms", report: true, want: []string{"default-encryption"}},
+		{encryption: "", report: false},
+		{encryption: "AES256", report: false},
+	}
+
+	for _, tt := range tests {
+		client := newFakeClient(t, &fakeS3{encryption: tt.encryption})
+		s, wait := newTestScanner(t, Options{ReportEncryption: tt.report})
+		s.checkBucketEncryption(context.Background(), client, "bucket")
+
+		var kinds []string
+		for _, f := range wait() {
+			kinds = append(kinds, f.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tt.want) {
+			t.Errorf("encryption %q, report %v: findings %v, want %v", tt.encryption, tt.report, kinds, tt.want)
+		}
+	}
+}
+
+func TestKMSWithoutBucketKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []types.ServerSideEncryptionRule
+		want  bool
+	}{
+		{"no rules", nil, false},
+		{"SSE-S3", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAes256, nil)}, false},
+		{"KMS with bucket key", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAwsKms, aws.Bool(true))}, false},
+		{"KMS with bucket key disabled", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAwsKms, aws.Bool(false))}, true},
+		{"KMS with bucket key unset", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAwsKms, nil)}, true},
+		{"DSSE-KMS", []types.ServerSideEncryptionRule{encryptionRule(types.ServerSideEncryptionAwsKmsDsse, nil)}, true},
+		{"rule without default", []types.ServerSideEncryptionRule{{}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kmsWithoutBucketKey(tt.rules); got != tt.want {
+				t.Errorf("kmsWithoutBucketKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
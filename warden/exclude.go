@@ -0,0 +1,28 @@
+package warden
+
+import (
+	"fmt"
+	"path"
+)
+
+// checkExcludes rejects a malformed pattern up front, since path.Match
+// only reports one when it is used
+func checkExcludes(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q, %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// excluded reports whether bucket is named, or matched by a pattern, in
+// Exclude
+func (s *Scanner) excluded(bucket string) bool {
+	for _, pattern := range s.opts.Exclude {
+		if matched, _ := path.Match(pattern, bucket); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package warden
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// LogDeliveryURI is the group S3 writes server access logs as
+const LogDeliveryURI = "http://acs.amazonaws.com/groups/s3/LogDelivery"
+
+// granteeLabel formats a grantee as its canonical ID, with the display name
+// when S3 still returns one, or as its email address or group URI
+func granteeLabel(grantee *types.Grantee) string {
+	switch grantee.Type {
+	case types.TypeCanonicalUser:
+		return ownerLabel(&types.Owner{ID: grantee.ID, DisplayName: grantee.DisplayName})
+	case types.TypeAmazonCustomerByEmail:
+		return aws.ToString(grantee.EmailAddress)
+	default:
+		return aws.ToString(grantee.URI)
+	}
+}
+
+// reportOtherGrantees reports the grants on a bucket ACL to the log
+// delivery group and to accounts other than the owner's, which the public
+// checks don't cover. A canonical user can only be told apart from the
+// owner when S3 names the owner.
+func (s *Scanner) reportOtherGrantees(bucket string, region string, owner *types.Owner, grants []types.Grant) {
+	ownerID := ""
+	if owner != nil {
+		ownerID = aws.ToString(owner.ID)
+	}
+
+	for _, grant := range grants {
+		if grant.Grantee == nil {
+			continue
+		}
+		grantee := grant.Grantee
+
+		var kind, message string
+		switch {
+		case grantee.Type == types.TypeGroup && aws.ToString(grantee.URI) == LogDeliveryURI:
+			kind = KindLogDeliveryGrant
+			message = fmt.Sprintf("Bucket %s grants %s to the S3 log delivery group", Printable(bucket), grant.Permission)
+		case grantee.Type == types.TypeCanonicalUser && ownerID != "" && aws.ToString(grantee.ID) != ownerID,
+			grantee.Type == types.TypeAmazonCustomerByEmail:
+			kind = KindCrossAccountGrant
+			message = fmt.Sprintf("Bucket %s grants %s to another account: %s", Printable(bucket), grant.Permission, Printable(granteeLabel(grantee)))
+		default:
+			continue
+		}
+
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   region,
+			Kind:     kind,
+			Severity: "INFORMATIONAL",
+			Message:  message,
+
+			Grantee:    granteeLabel(grantee),
+			Permission: string(grant.Permission),
+			Source:     "acl",
+		})
+	}
+}
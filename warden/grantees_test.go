@@ -0,0 +1,51 @@
+package warden
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestCheckBucketACLOtherGrantees(t *testing.T) {
+	fake := &fakeS3{bucketGrants: []string{
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>other</ID></Grantee><Permission>READ</Permission></Grant>`,
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>` + LogDeliveryURI + `</URI></Grantee><Permission>WRITE</Permission></Grant>`,
+	}}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{})
+	if _, err := s.checkBucketACL(context.Background(), client, "bucket"); err != nil {
+		t.Fatal(err)
+	}
+	findings := wait()
+
+	// the owner's own FULL_CONTROL grant isn't reported
+	want := map[string]Finding{
+		KindCrossAccountGrant: {Grantee: "other", Permission: "READ"},
+		KindLogDeliveryGrant:  {Grantee: LogDeliveryURI, Permission: "WRITE"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("findings = %+v, want %d", findings, len(want))
+	}
+	for _, f := range findings {
+		w, ok := want[f.Kind]
+		if !ok || f.Grantee != w.Grantee || f.Permission != w.Permission || f.Severity != "INFORMATIONAL" {
+			t.Errorf("finding = %+v, want an INFORMATIONAL %s grant of %s to %s", f, f.Kind, w.Permission, w.Grantee)
+		}
+	}
+}
+
+func TestReportOtherGranteesWithoutOwner(t *testing.T) {
+	grants := []types.Grant{{
+		Grantee:    &types.Grantee{Type: types.TypeCanonicalUser, ID: aws.String("someone")},
+		Permission: types.PermissionRead,
+	}}
+
+	s, wait := newTestScanner(t, Options{})
+	s.reportOtherGrantees("bucket", "us-east-1", nil, grants)
+	if findings := wait(); len(findings) != 0 {
+		t.Errorf("findings = %+v, want none when the owner is unknown", findings)
+	}
+}
@@ -0,0 +1,347 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestIterateBucketFanout(t *testing.T) {
+	keys := []string{"a/1", "a/2", "b/1", "b/c/1", "c/1", "top"}
+
+	for _, workers := range []int{1, 3} {
+		fake := &fakeS3{keys: keys}
+		client := newFakeClient(t, fake)
+
+		s, wait := newTestScanner(t, Options{Fanout: workers})
+		s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+		wait()
+
+		want := append([]string(nil), keys...)
+		sort.Strings(want)
+		if got := fake.checked(); !reflect.DeepEqual(got, want) {
+			t.Errorf("fanout %d checked %v, want %v", workers, got, want)
+		}
+	}
+}
+
+func TestIterateBucketObjectConcurrency(t *testing.T) {
+	keys := []string{"a/1", "a/2", "b/1", "b/c/1", "c/1", "top"}
+
+	for _, fanout := range []int{1, 2} {
+		fake := &fakeS3{keys: keys}
+		client := newFakeClient(t, fake)
+
+		s, wait := newTestScanner(t, Options{Fanout: fanout, ObjectConcurrency: 4})
+		s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+		wait()
+
+		want := append([]string(nil), keys...)
+		sort.Strings(want)
+		if got := fake.checked(); !reflect.DeepEqual(got, want) {
+			t.Errorf("fanout %d checked %v, want %v", fanout, got, want)
+		}
+	}
+}
+
+func TestIterateBucketObjectConcurrencyKeepsCap(t *testing.T) {
+	fake := &fakeS3{publicKeys: map[string]bool{}}
+	for i := 0; i < 40; i++ {
+		key := fmt.Sprintf("k%02d", i)
+		fake.keys = append(fake.keys, key)
+		fake.publicKeys[key] = true
+	}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{ObjectConcurrency: 8})
+	s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+	findings := wait()
+
+	outliers := 0
+	for _, f := range findings {
+		if f.Kind == "object-acl-outlier" {
+			outliers++
+		}
+	}
+	if outliers != 5 {
+		t.Errorf("reported %d outliers, want 5 however many were checked at once", outliers)
+	}
+	if got := len(fake.checked()); got >= len(fake.keys) {
+		t.Errorf("checked all %d objects, want the scan to stop early", got)
+	}
+}
+
+func TestIterateBucketStopsAfterFiveIssues(t *testing.T) {
+	fake := &fakeS3{publicKeys: map[string]bool{}}
+	for _, key := range []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"} {
+		fake.keys = append(fake.keys, key)
+		fake.publicKeys[key] = true
+	}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{})
+	s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+	findings := wait()
+
+	if got := len(fake.checked()); got != 5 {
+		t.Errorf("checked %d objects, want 5 before stopping", got)
+	}
+	if len(findings) != 6 {
+		t.Fatalf("reported %d findings, want one outlier per checked object and a scan-incomplete", len(findings))
+	}
+	for _, f := range findings[:5] {
+		if f.Kind != "object-acl-outlier" || f.Severity != "HIGH" {
+			t.Errorf("finding = %s/%s, want a HIGH object-acl-outlier", f.Kind, f.Severity)
+		}
+	}
+	if last := findings[5]; last.Kind != "scan-incomplete" || !last.Incomplete || last.Reason != "stopped after 5 objects with public access issues" {
+		t.Errorf("last finding = %+v, want scan-incomplete for the issue cap", last)
+	}
+}
+
+func TestIterateBucketListingFailureIsIncomplete(t *testing.T) {
+	fake := &fakeS3{keys: []string{"a/1", "b/1"}, failPrefix: "b/"}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{Fanout: 2})
+	s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+	findings := wait()
+
+	if len(findings) != 1 || findings[0].Kind != "scan-incomplete" || findings[0].Reason != "listing the objects failed" {
+		t.Errorf("findings = %+v, want one scan-incomplete for the failed listing", findings)
+	}
+}
+
+func TestIterateBucketCountPastCap(t *testing.T) {
+	fake := &fakeS3{publicKeys: map[string]bool{}}
+	for _, key := range []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"} {
+		fake.keys = append(fake.keys, key)
+		fake.publicKeys[key] = true
+	}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{CountPastCap: true})
+	s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+	findings := wait()
+
+	if got := len(fake.checked()); got != 8 {
+		t.Errorf("checked %d objects, want all 8", got)
+	}
+
+	counts := map[string]int{}
+	for _, f := range findings {
+		counts[f.Kind]++
+	}
+	if counts["object-acl-outlier"] != 5 || counts["object-issue-count"] != 1 {
+		t.Errorf("findings by kind = %v, want 5 outliers and one total", counts)
+	}
+	last := findings[len(findings)-1]
+	if want := "Reported 5 of 8 objects with public access issues in bucket"; last.Message != want {
+		t.Errorf("total message = %q, want %q", last.Message, want)
+	}
+}
+
+func TestIterateBucketSkipPrefixes(t *testing.T) {
+	keys := []string{"media/big/1", "media/small/1", "logs/1", "uploads/1", "uploads.txt", "top"}
+
+	for _, workers := range []int{1, 3} {
+		fake := &fakeS3{keys: keys}
+		client := newFakeClient(t, fake)
+
+		s, wait := newTestScanner(t, Options{Fanout: workers, SkipPrefixes: []string{"uploads", "media/big/"}})
+		s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+		wait()
+
+		want := []string{"logs/1", "media/small/1", "top"}
+		if got := fake.checked(); !reflect.DeepEqual(got, want) {
+			t.Errorf("fanout %d checked %v, want %v", workers, got, want)
+		}
+		for _, prefix := range fake.listed {
+			if prefix == "uploads/" || prefix == "media/big/" {
+				t.Errorf("fanout %d listed skipped prefix %s", workers, prefix)
+			}
+		}
+	}
+}
+
+func TestIterateBucketPrefix(t *testing.T) {
+	keys := []string{"backups/2023/1", "backups/2024/1", "backups.txt", "logs/1", "top"}
+
+	for _, workers := range []int{1, 3} {
+		fake := &fakeS3{keys: keys}
+		client := newFakeClient(t, fake)
+
+		s, wait := newTestScanner(t, Options{Fanout: workers, Prefix: "backups/"})
+		s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+		wait()
+
+		want := []string{"backups/2023/1", "backups/2024/1"}
+		if got := fake.checked(); !reflect.DeepEqual(got, want) {
+			t.Errorf("fanout %d checked %v, want %v", workers, got, want)
+		}
+		for _, prefix := range fake.listed {
+			if !strings.HasPrefix(prefix, "backups/") {
+				t.Errorf("fanout %d listed %q outside the prefix", workers, prefix)
+			}
+		}
+	}
+}
+
+func TestIterateBucketTimeoutIsIncomplete(t *testing.T) {
+	fake := &fakeS3{keys: []string{"a"}}
+	client := newFakeClient(t, fake)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	s, wait := newTestScanner(t, Options{})
+	s.iterateBucket(ctx, client, "bucket", &aclSummary{})
+	findings := wait()
+
+	if len(findings) != 1 || findings[0].Reason != "the bucket timed out" {
+		t.Errorf("findings = %+v, want one scan-incomplete for the timeout", findings)
+	}
+}
+
+func TestIterateBucketCheckTimeoutSkipsObject(t *testing.T) {
+	fake := &fakeS3{keys: []string{"a", "hung", "z"}, hungKeys: map[string]bool{"hung": true}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, wait := newTestScanner(t, Options{CheckTimeout: 50 * time.Millisecond})
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+		APIOptions:   []func(*middleware.Stack) error{s.addCheckTimeoutMiddleware},
+	})
+	s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+	findings := wait()
+
+	// the hung call gives up on its own deadline, not the bucket's
+	if got, want := fake.checked(), []string{"a", "z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("checked %v, want %v", got, want)
+	}
+	for _, f := range findings {
+		if f.Incomplete {
+			t.Errorf("found %+v, want the bucket finished after skipping one object", f)
+		}
+	}
+}
+
+func TestIterateBucketMaxFindings(t *testing.T) {
+	tests := []struct {
+		max     int
+		checked int
+	}{
+		{max: 2, checked: 2},
+		{max: -1, checked: 8},
+	}
+
+	for _, tt := range tests {
+		fake := &fakeS3{publicKeys: map[string]bool{}}
+		for _, key := range []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"} {
+			fake.keys = append(fake.keys, key)
+			fake.publicKeys[key] = true
+		}
+		client := newFakeClient(t, fake)
+
+		s, wait := newTestScanner(t, Options{MaxFindings: tt.max})
+		s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+		findings := wait()
+
+		if got := len(fake.checked()); got != tt.checked {
+			t.Errorf("max %d checked %d objects, want %d", tt.max, got, tt.checked)
+		}
+		outliers := 0
+		for _, f := range findings {
+			if f.Kind == "object-acl-outlier" {
+				outliers++
+			}
+		}
+		if outliers != tt.checked {
+			t.Errorf("max %d reported %d outliers, want %d", tt.max, outliers, tt.checked)
+		}
+	}
+}
+
+func TestIterateBucketCountPublicRead(t *testing.T) {
+	fake := &fakeS3{publicKeys: map[string]bool{}}
+	for _, key := range []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"} {
+		fake.keys = append(fake.keys, key)
+		fake.publicKeys[key] = true
+	}
+
+	for _, countRead := range []bool{false, true} {
+		client := newFakeClient(t, fake)
+		fake.aclChecks = nil
+
+		// with a public bucket ACL the public objects aren't outliers, so
+		// they only count as issues with CountPublicRead
+		s, wait := newTestScanner(t, Options{MaxFindings: 2, CountPublicRead: countRead})
+		s.iterateBucket(context.Background(), client, "bucket", &aclSummary{publicRead: true})
+		wait()
+
+		want := 8
+		if countRead {
+			want = 2
+		}
+		if got := len(fake.checked()); got != want {
+			t.Errorf("count public read %v checked %d objects, want %d", countRead, got, want)
+		}
+	}
+}
+
+func TestIterateBucketSample(t *testing.T) {
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5"}
+	tests := []struct {
+		name        string
+		opts        Options
+		public      bool
+		wantChecked int
+		wantReason  string
+	}{
+		{"stops at the sample", Options{Sample: 3}, false, 3, "sampled the first 3 objects"},
+		{"sample of every object", Options{Sample: len(keys)}, false, len(keys), ""},
+		{"issue cap first", Options{Sample: 4, MaxFindings: 2}, true, 2, "stopped after 2 objects with public access issues"},
+		{"sample first", Options{Sample: 2, MaxFindings: 4}, true, 2, "sampled the first 2 objects"},
+		{"concurrent", Options{Sample: 3, ObjectConcurrency: 4}, false, 3, "sampled the first 3 objects"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeS3{keys: keys, publicKeys: map[string]bool{}}
+			for _, key := range keys {
+				fake.publicKeys[key] = tt.public
+			}
+			client := newFakeClient(t, fake)
+
+			s, wait := newTestScanner(t, tt.opts)
+			s.iterateBucket(context.Background(), client, "bucket", &aclSummary{})
+			findings := wait()
+
+			if got := len(fake.checked()); got != tt.wantChecked {
+				t.Errorf("checked %d objects, want %d", got, tt.wantChecked)
+			}
+			reason := ""
+			for _, f := range findings {
+				if f.Incomplete {
+					reason = f.Reason
+				}
+			}
+			if reason != tt.wantReason {
+				t.Errorf("incomplete reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
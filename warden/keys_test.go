@@ -0,0 +1,29 @@
+package warden
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCheckKeysCommonKeys(t *testing.T) {
+	fake := &fakeS3{keys: []string{".env", "robots.txt", "not-common.txt"}}
+	client := newFakeClient(t, fake)
+
+	s, wait := newTestScanner(t, Options{ProbeCommonKeys: true})
+	s.checkKeys(context.Background(), client, "bucket", CommonKeys, nil)
+	findings := wait()
+
+	var readable []string
+	for _, f := range findings {
+		if f.Kind == "object-readable" {
+			readable = append(readable, f.Key)
+		}
+	}
+	if want := []string{".env", "robots.txt"}; !reflect.DeepEqual(readable, want) {
+		t.Errorf("readable keys = %v, want %v", readable, want)
+	}
+	if want := []string{".env", "robots.txt"}; !reflect.DeepEqual(fake.checked(), want) {
+		t.Errorf("ACL checked on %v, want only the readable keys", fake.checked())
+	}
+}
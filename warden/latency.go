@@ -0,0 +1,95 @@
+package warden
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// recordLatency keeps the duration of a call for Latencies and warns when
+// it exceeded the configured threshold
+func (s *Scanner) recordLatency(operation string, bucket string, elapsed time.Duration) {
+	if s.opts.SlowThreshold > 0 && elapsed > s.opts.SlowThreshold {
+		s.log.Warnf("Slow %s call on %s took %s", operation, Printable(bucket), elapsed.Round(time.Millisecond))
+	}
+
+	if !s.opts.LatencyStats {
+		return
+	}
+	s.latencyMu.Lock()
+	s.latencySamples[operation] = append(s.latencySamples[operation], elapsed)
+	s.latencyMu.Unlock()
+}
+
+// Latencies returns the duration of every call made so far, by operation.
+// Calls are only timed when LatencyStats is set.
+func (s *Scanner) Latencies() map[string][]time.Duration {
+	s.latencyMu.Lock()
+	defer s.latencyMu.Unlock()
+
+	latencies := make(map[string][]time.Duration, len(s.latencySamples))
+	for operation, samples := range s.latencySamples {
+		latencies[operation] = append([]time.Duration(nil), samples...)
+	}
+	return latencies
+}
+
+// addLatencyMiddleware times every S3 operation, including any retries
+// the SDK makes on our behalf
+func (s *Scanner) addLatencyMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("S3WardenLatency", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		s.recordLatency(awsmiddleware.GetOperationName(ctx), inputBucket(in.Parameters), time.Since(start))
+		return out, metadata, err
+	}), middleware.After)
+}
+
+// addCheckTimeoutMiddleware gives every S3 operation, retries included, its
+// own deadline so one hung call can't hold up the rest of a bucket's checks
+func (s *Scanner) addCheckTimeoutMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("S3WardenCheckTimeout", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		ctx, cancel := context.WithTimeout(ctx, s.opts.CheckTimeout)
+		defer cancel()
+		return next.HandleInitialize(ctx, in)
+	}), middleware.Before)
+}
+
+// inputBucket pulls the bucket name out of the S3 operation inputs we use
+func inputBucket(params interface{}) string {
+	switch input := params.(type) {
+	case *s3.GetBucketAclInput:
+		return aws.ToString(input.Bucket)
+	case *s3.ListObjectsV2Input:
+		return aws.ToString(input.Bucket)
+	case *s3.GetObjectAclInput:
+		return aws.ToString(input.Bucket)
+	case *s3.HeadObjectInput:
+		return aws.ToString(input.Bucket)
+	case *s3.GetBucketEncryptionInput:
+		return aws.ToString(input.Bucket)
+	case *s3.GetBucketPolicyInput:
+		return aws.ToString(input.Bucket)
+	case *s3.GetBucketTaggingInput:
+		return aws.ToString(input.Bucket)
+	case *s3.GetPublicAccessBlockInput:
+		return aws.ToString(input.Bucket)
+	case *s3.GetBucketVersioningInput:
+		return aws.ToString(input.Bucket)
+	case *s3.GetBucketWebsiteInput:
+		return aws.ToString(input.Bucket)
+	case *s3.DeleteObjectInput:
+		return aws.ToString(input.Bucket)
+	case *s3.PutObjectInput:
+		return aws.ToString(input.Bucket)
+	case *s3.PutBucketAclInput:
+		return aws.ToString(input.Bucket)
+	case *s3.PutObjectAclInput:
+		return aws.ToString(input.Bucket)
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestInputBucket(t *testing.T) {
+	tests := []struct {
+		name   string
+		params interface{}
+		want   string
+	}{
+		{"bucket acl", &s3.GetBucketAclInput{Bucket: aws.String("a")}, "a"},
+		{"object acl", &s3.GetObjectAclInput{Bucket: aws.String("b"), Key: aws.String("k")}, "b"},
+		{"nil bucket", &s3.ListObjectsV2Input{}, ""},
+		{"unknown input", "not an input", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inputBucket(tt.params); got != tt.want {
+				t.Errorf("inputBucket() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTimeoutMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	s, err := New(Options{CheckTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s3.New(s3.Options{
+		Region:           "us-east-1",
+		BaseEndpoint:     aws.String(server.URL),
+		UsePathStyle:     true,
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+		APIOptions:       []func(*middleware.Stack) error{s.addCheckTimeoutMiddleware},
+	})
+
+	start := time.Now()
+	_, err = client.GetBucketAcl(context.Background(), &s3.GetBucketAclInput{Bucket: aws.String("slow")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetBucketAcl error = %v, want the check deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetBucketAcl took %s, want it cut short", elapsed)
+	}
+}
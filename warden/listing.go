@@ -0,0 +1,67 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// listingSize is what paging through a listable bucket found
+type listingSize struct {
+	objects int64
+	bytes   int64
+	// partial is set when the listing stopped before the end, so the
+	// totals are a lower bound
+	partial bool
+}
+
+// measureListing pages through a bucket's objects adding up their count and
+// size, stopping after Sample objects when it is set
+func (s *Scanner) measureListing(ctx context.Context, client *s3.Client, bucket string) listingSize {
+	var size listingSize
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			s.logFailure(err, "Failed to measure the listing of %s, its totals are partial", Printable(bucket))
+			size.partial = true
+			return size
+		}
+		for _, object := range page.Contents {
+			if s.opts.Sample > 0 && size.objects >= int64(s.opts.Sample) {
+				size.partial = true
+				return size
+			}
+			size.objects++
+			size.bytes += aws.ToInt64(object.Size)
+		}
+	}
+	return size
+}
+
+// text describes the totals for a finding's message
+func (l listingSize) text() string {
+	atLeast := ""
+	if l.partial {
+		atLeast = "at least "
+	}
+	return fmt.Sprintf("%s%d objects, %s", atLeast, l.objects, formatBytes(l.bytes))
+}
+
+// formatBytes writes a size in the largest binary unit it reaches
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exp])
+}
@@ -0,0 +1,70 @@
+package warden
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 30, "5.0 GiB"},
+		{3 << 40, "3.0 TiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOpenListingSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		wantObjects int64
+		wantText    string
+	}{
+		{"every object", Options{ListingSize: true}, 3, "(3 objects, 3 B)"},
+		{"sampled", Options{ListingSize: true, Sample: 2}, 2, "(at least 2 objects, 2 B)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(t, &fakeS3{keys: []string{"a", "b", "c"}})
+			s, wait := newTestScanner(t, tt.opts)
+			listable, _ := s.checkOpenListing(context.Background(), client, "bucket")
+			findings := wait()
+
+			if !listable || len(findings) != 1 {
+				t.Fatalf("listable = %v with findings %+v, want one open-listing", listable, findings)
+			}
+			f := findings[0]
+			if f.ObjectCount == nil || *f.ObjectCount != tt.wantObjects || f.TotalSize == nil || *f.TotalSize != tt.wantObjects {
+				t.Errorf("ObjectCount, TotalSize = %v, %v, want %d of 1 byte each", f.ObjectCount, f.TotalSize, tt.wantObjects)
+			}
+			if !strings.HasSuffix(f.Message, tt.wantText) {
+				t.Errorf("Message = %q, want it to end %q", f.Message, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestCheckOpenListingWithoutSize(t *testing.T) {
+	client := newFakeClient(t, &fakeS3{keys: []string{"a"}})
+	s, wait := newTestScanner(t, Options{})
+	s.checkOpenListing(context.Background(), client, "bucket")
+	findings := wait()
+
+	if len(findings) != 1 || findings[0].ObjectCount != nil || findings[0].TotalSize != nil {
+		t.Errorf("findings = %+v, want an open-listing without totals", findings)
+	}
+}
@@ -0,0 +1,86 @@
+package warden
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ownerLabel formats an owner as its ID, with the display name when S3
+// still returns one
+func ownerLabel(owner *types.Owner) string {
+	id := aws.ToString(owner.ID)
+	if name := aws.ToString(owner.DisplayName); name != "" {
+		return fmt.Sprintf("%s (%s)", id, name)
+	}
+	return id
+}
+
+// recordOwner keeps the owner disclosed by an ACL for Owners
+func (s *Scanner) recordOwner(bucket string, region string, owner *types.Owner) {
+	if owner == nil || aws.ToString(owner.ID) == "" {
+		return
+	}
+	s.log.Debugf("Bucket %s is owned by %s", Printable(bucket), Printable(ownerLabel(owner)))
+
+	s.ownersMu.Lock()
+	s.bucketOwners[bucket] = ownerLabel(owner)
+	s.ownersMu.Unlock()
+	s.recordDisclosure(bucket, region, aws.ToString(owner.ID), ownerLabel(owner), "acl")
+}
+
+// BucketOwner returns the owner a bucket's ACL disclosed, as its canonical
+// user ID with any display name, or "" if its ACL couldn't be read
+func (s *Scanner) BucketOwner(bucket string) string {
+	s.ownersMu.Lock()
+	defer s.ownersMu.Unlock()
+	return s.bucketOwners[bucket]
+}
+
+// recordDisclosure keeps an owner or account disclosed by a bucket's ACL or
+// policy for Owners, and reports it when ReportOwners is set. id is the
+// canonical user or account ID and label how it is shown.
+func (s *Scanner) recordDisclosure(bucket string, region string, id string, label string, source string) {
+	s.ownersMu.Lock()
+	if s.owners[label] == nil {
+		s.owners[label] = map[string]bool{}
+	}
+	seen := s.owners[label][bucket]
+	s.owners[label][bucket] = true
+	s.ownersMu.Unlock()
+
+	// a bucket retried in another region reads its ACL again
+	if !s.opts.ReportOwners || seen {
+		return
+	}
+	message := fmt.Sprintf("Bucket %s is owned by %s", Printable(bucket), Printable(label))
+	if source == "policy" {
+		message = fmt.Sprintf("Bucket policy of %s names account %s", Printable(bucket), Printable(label))
+	}
+	s.report(Finding{
+		Bucket:   bucket,
+		Region:   region,
+		Kind:     KindOwnerDisclosed,
+		Severity: "INFORMATIONAL",
+		Message:  message,
+		Account:  id,
+		Source:   source,
+	})
+}
+
+// Owners returns the number of buckets seen for each owner disclosed by a
+// bucket ACL, and each account named by a bucket policy. S3 reports owners
+// by canonical user ID, which is stable per AWS account and so is enough
+// to tie buckets to the same account, though it can't be turned into the
+// account ID. Policies name accounts by their account ID.
+func (s *Scanner) Owners() map[string]int {
+	s.ownersMu.Lock()
+	defer s.ownersMu.Unlock()
+
+	counts := make(map[string]int, len(s.owners))
+	for label, buckets := range s.owners {
+		counts[label] = len(buckets)
+	}
+	return counts
+}
@@ -0,0 +1,56 @@
+package warden
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestRecordOwner(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := &types.Owner{ID: aws.String("abc123"), DisplayName: aws.String("alice")}
+	anonymous := &types.Owner{ID: aws.String("def456")}
+
+	s.recordOwner("one", "us-east-1", alice)
+	s.recordOwner("two", "us-east-1", alice)
+	s.recordOwner("two", "us-east-1", alice)
+	s.recordOwner("three", "us-east-1", anonymous)
+	s.recordOwner("four", "us-east-1", nil)
+	s.recordOwner("five", "us-east-1", &types.Owner{})
+
+	owners := s.Owners()
+	if len(owners) != 2 {
+		t.Fatalf("recorded %d owners, want 2: %v", len(owners), owners)
+	}
+	if got := owners["abc123 (alice)"]; got != 2 {
+		t.Errorf("alice owns %d buckets, want 2", got)
+	}
+	if got := owners["def456"]; got != 1 {
+		t.Errorf("owner without display name owns %d buckets, want 1", got)
+	}
+}
+
+func TestRecordDisclosureReportsOnce(t *testing.T) {
+	s, wait := newTestScanner(t, Options{ReportOwners: true})
+	owner := &types.Owner{ID: aws.String("abc123")}
+	s.recordOwner("bucket", "us-east-1", owner)
+	// a bucket retried in another region reads its ACL again
+	s.recordOwner("bucket", "eu-west-1", owner)
+	s.recordDisclosure("bucket", "eu-west-1", "111122223333", "111122223333", "policy")
+	findings := wait()
+
+	if len(findings) != 2 {
+		t.Fatalf("findings = %+v, want the ACL owner and the policy account once each", findings)
+	}
+	if f := findings[0]; f.Kind != KindOwnerDisclosed || f.Account != "abc123" || f.Source != "acl" {
+		t.Errorf("finding = %+v, want the ACL owner abc123", f)
+	}
+	if f := findings[1]; f.Account != "111122223333" || f.Source != "policy" {
+		t.Errorf("finding = %+v, want the policy account 111122223333", f)
+	}
+}
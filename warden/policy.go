@@ -0,0 +1,298 @@
+package warden
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// stringOrList decodes policy fields that may be a single string or a list
+type stringOrList []string
+
+func (l *stringOrList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = stringOrList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// policyPrincipal is "*" or a map such as {"AWS": ["*"]}, along with the
+// account IDs of the AWS principals it names
+type policyPrincipal struct {
+	wildcard bool
+	accounts []string
+}
+
+// principalAccount finds the account ID in an AWS principal, given as a
+// bare account ID or as an IAM or STS ARN
+var principalAccount = regexp.MustCompile(`^(?:arn:aws[a-z-]*:(?:iam|sts)::)?([0-9]{12})(?::|$)`)
+
+func (p *policyPrincipal) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		p.wildcard = single == "*"
+		return nil
+	}
+	var byType map[string]stringOrList
+	if err := json.Unmarshal(data, &byType); err != nil {
+		return err
+	}
+	for _, principal := range byType["AWS"] {
+		if principal == "*" {
+			p.wildcard = true
+		}
+		if m := principalAccount.FindStringSubmatch(principal); m != nil {
+			p.accounts = appendNew(p.accounts, m[1])
+		}
+	}
+	return nil
+}
+
+type policyStatement struct {
+	Effect    string
+	Principal policyPrincipal
+	Action    stringOrList
+	Condition map[string]map[string]json.RawMessage
+}
+
+// policyStatements lets Statement be a single object or a list
+type policyStatements []policyStatement
+
+func (s *policyStatements) UnmarshalJSON(data []byte) error {
+	var single policyStatement
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = policyStatements{single}
+		return nil
+	}
+	var list []policyStatement
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+type bucketPolicy struct {
+	Statement policyStatements
+}
+
+// policyExposure is how far a bucket policy opens a bucket to everyone
+type policyExposure int
+
+const (
+	policyPrivate policyExposure = iota
+	policyVPCRestricted
+	policyPublic
+)
+
+// vpcOperators are the condition operators that only match requests whose
+// source VPC or VPC endpoint is one of those given. Negated operators allow
+// every other source, IfExists variants also match requests that have no
+// source VPC at all, such as those from the internet, and Null only tests
+// whether the key is there.
+var vpcOperators = map[string]bool{
+	"stringequals":             true,
+	"stringlike":               true,
+	"foranyvalue:stringequals": true,
+	"foranyvalue:stringlike":   true,
+}
+
+// vpcRestricted reports whether a statement only applies to requests from
+// a VPC or VPC endpoint, the usual way of granting "*" to VPC-only access
+func (st policyStatement) vpcRestricted() bool {
+	for operator, keys := range st.Condition {
+		if !vpcOperators[strings.ToLower(operator)] {
+			continue
+		}
+		for key := range keys {
+			switch strings.ToLower(key) {
+			case "aws:sourcevpce", "aws:sourcevpc":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// analyzePolicy classifies a bucket policy by its most open Allow statement
+// for a wildcard principal, along with the actions granted by every
+// statement that open
+func analyzePolicy(document string) (policyExposure, []string, error) {
+	var policy bucketPolicy
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return policyPrivate, nil, err
+	}
+
+	exposure := policyPrivate
+	var actions []string
+	for _, st := range policy.Statement {
+		if st.Effect != "Allow" || !st.Principal.wildcard {
+			continue
+		}
+		statementExposure := policyPublic
+		if st.vpcRestricted() {
+			statementExposure = policyVPCRestricted
+		}
+		if statementExposure > exposure {
+			exposure = statementExposure
+			actions = nil
+		}
+		if statementExposure == exposure {
+			actions = appendNew(actions, st.Action...)
+		}
+	}
+	return exposure, actions, nil
+}
+
+// appendNew appends the values not already in list
+func appendNew(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// writeActions are the object write permissions that let anyone change a
+// bucket's contents, found without having to upload anything
+var writeActions = []string{"s3:PutObject", "s3:PutObjectAcl", "s3:DeleteObject"}
+
+// matchesAction reports whether a policy action, which may use the * and ?
+// wildcards, covers action. Actions are case-insensitive.
+func matchesAction(pattern, action string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(action))
+	return err == nil && matched
+}
+
+// publicWriteActions lists the writeActions that the policy grants to
+// everyone outside a VPC
+func publicWriteActions(document string) []string {
+	var policy bucketPolicy
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return nil
+	}
+
+	var granted []string
+	for _, action := range writeActions {
+	statements:
+		for _, st := range policy.Statement {
+			if st.Effect != "Allow" || !st.Principal.wildcard || st.vpcRestricted() {
+				continue
+			}
+			for _, pattern := range st.Action {
+				if matchesAction(pattern, action) {
+					granted = append(granted, action)
+					break statements
+				}
+			}
+		}
+	}
+	return granted
+}
+
+// policyAccounts lists the account IDs named as principals anywhere in the
+// policy, whatever the statement's effect
+func policyAccounts(document string) []string {
+	var policy bucketPolicy
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return nil
+	}
+
+	var accounts []string
+	for _, st := range policy.Statement {
+		accounts = appendNew(accounts, st.Principal.accounts...)
+	}
+	return accounts
+}
+
+// checkBucketPolicy reports a bucket policy that grants access to everyone,
+// and reports whether the policy could be read
+func (s *Scanner) checkBucketPolicy(ctx context.Context, client *s3.Client, bucket string) bool {
+	output, err := client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucketPolicy" {
+			s.log.Debugf("Bucket %s has no bucket policy", Printable(bucket))
+			return true
+		}
+		s.logFailure(err, "Failed to get the bucket policy for %s", Printable(bucket))
+		return false
+	}
+
+	exposure, actions, err := analyzePolicy(aws.ToString(output.Policy))
+	if err != nil {
+		s.log.Errorf("Unable to parse the bucket policy for %s, %v", Printable(bucket), err)
+		return true
+	}
+	for _, account := range policyAccounts(aws.ToString(output.Policy)) {
+		s.recordDisclosure(bucket, client.Options().Region, account, account, "policy")
+	}
+
+	switch exposure {
+	case policyPublic:
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindPolicyPublic,
+			Severity: "HIGH",
+			Message:  fmt.Sprintf("Bucket policy grants public access (%s): %s", Printable(strings.Join(actions, ", ")), Printable(bucket)),
+
+			Grantee:    "*",
+			Permission: strings.Join(actions, ","),
+			Source:     "policy",
+		})
+	case policyVPCRestricted:
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindPolicyVPCRestricted,
+			Severity: "INFORMATIONAL",
+			Message:  fmt.Sprintf("Bucket policy grants access to everyone within a VPC (%s): %s", Printable(strings.Join(actions, ", ")), Printable(bucket)),
+
+			Grantee:    "*",
+			Permission: strings.Join(actions, ","),
+			Source:     "policy",
+		})
+	default:
+		s.log.Debugf("Bucket policy for %s grants no public access", Printable(bucket))
+	}
+
+	if writes := publicWriteActions(aws.ToString(output.Policy)); len(writes) > 0 {
+		s.report(Finding{
+			Bucket:   bucket,
+			Region:   client.Options().Region,
+			Kind:     KindPolicyPublicWrite,
+			Severity: "HIGH",
+			Message:  fmt.Sprintf("Bucket policy lets anyone write objects: %s", Printable(bucket)),
+
+			Grantee:    "*",
+			Permission: strings.Join(writes, ","),
+			Source:     "policy",
+		})
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package warden
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		want    policyExposure
+		actions []string
+		wantErr bool
+	}{
+		{
+			name:    "public read",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name:    "wildcard AWS principal",
+			policy:  `{"Statement":{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject","s3:PutObject"]}}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject", "s3:PutObject"},
+		},
+		{
+			name:    "restricted to a VPC endpoint",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Condition":{"StringEquals":{"aws:SourceVpce":"vpce-1a2b3c4d"}}}]}`,
+			want:    policyVPCRestricted,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name:    "restricted to a VPC, lowercase key",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:*","Condition":{"StringLike":{"aws:sourcevpc":"vpc-*"}}}]}`,
+			want:    policyVPCRestricted,
+			actions: []string{"s3:*"},
+		},
+		{
+			name:    "restricted to any of several VPC endpoints",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Condition":{"ForAnyValue:StringEquals":{"aws:SourceVpce":["vpce-1","vpce-2"]}}}]}`,
+			want:    policyVPCRestricted,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name:    "every endpoint but one",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Condition":{"StringNotEquals":{"aws:SourceVpce":"vpce-1"}}}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name:    "every VPC not like one",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Condition":{"StringNotLike":{"aws:SourceVpc":"vpc-*"}}}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name:    "IfExists matches requests from outside any VPC",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Condition":{"StringEqualsIfExists":{"aws:SourceVpce":"vpce-1"}}}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name:    "StringLikeIfExists matches requests from outside any VPC",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Condition":{"StringLikeIfExists":{"aws:SourceVpc":"vpc-*"}}}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name:    "Null only tests whether the key is there",
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Condition":{"Null":{"aws:SourceVpce":"true"}}}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name: "public statement outranks a VPC one",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:*","Condition":{"StringEquals":{"aws:SourceVpce":"vpce-1"}}},
+				{"Effect":"Allow","Principal":"*","Action":"s3:GetObject"}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject"},
+		},
+		{
+			name: "actions from every public statement",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":"*","Action":["s3:GetObject","s3:ListBucket"]},
+				{"Effect":"Allow","Principal":"*","Action":["s3:GetObject","s3:PutObject"]}]}`,
+			want:    policyPublic,
+			actions: []string{"s3:GetObject", "s3:ListBucket", "s3:PutObject"},
+		},
+		{
+			name:   "named principal",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"s3:*"}]}`,
+			want:   policyPrivate,
+		},
+		{
+			name:   "wildcard deny",
+			policy: `{"Statement":[{"Effect":"Deny","Principal":"*","Action":"s3:*"}]}`,
+			want:   policyPrivate,
+		},
+		{
+			name:    "not json",
+			policy:  `nope`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, actions, err := analyzePolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("analyzePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("analyzePolicy() exposure = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(actions, tt.actions) {
+				t.Errorf("analyzePolicy() actions = %v, want %v", actions, tt.actions)
+			}
+		})
+	}
+}
+
+func TestPublicWriteActions(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   []string
+	}{
+		{
+			name:   "read only",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject"}]}`,
+		},
+		{
+			name:   "put object",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":["s3:GetObject","s3:PutObject"]}]}`,
+			want:   []string{"s3:PutObject"},
+		},
+		{
+			name:   "wildcard across statements",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:Put*"},{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"S3:DeleteObject"}]}`,
+			want:   []string{"s3:PutObject", "s3:PutObjectAcl", "s3:DeleteObject"},
+		},
+		{
+			name:   "all actions",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"*"}]}`,
+			want:   []string{"s3:PutObject", "s3:PutObjectAcl", "s3:DeleteObject"},
+		},
+		{
+			name:   "restricted to a VPC endpoint",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:*","Condition":{"StringEquals":{"aws:SourceVpce":"vpce-1"}}}]}`,
+		},
+		{
+			name:   "every endpoint but one",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:PutObject","Condition":{"StringNotEquals":{"aws:SourceVpce":"vpce-1"}}}]}`,
+			want:   []string{"s3:PutObject"},
+		},
+		{
+			name:   "named principal",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"s3:PutObject"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := publicWriteActions(tt.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("publicWriteActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyAccounts(t *testing.T) {
+	policy := `{"Statement":[
+		{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::111122223333:root","444455556666"]},"Action":"s3:GetObject"},
+		{"Effect":"Deny","Principal":{"AWS":"arn:aws:sts::777788889999:assumed-role/audit/session"},"Action":"s3:*"},
+		{"Effect":"Allow","Principal":{"AWS":["*","arn:aws:iam::111122223333:role/reader"]},"Action":"s3:ListBucket"},
+		{"Effect":"Allow","Principal":{"Service":"logging.s3.amazonaws.com"},"Action":"s3:PutObject"}]}`
+
+	want := []string{"111122223333", "444455556666", "777788889999"}
+	if got := policyAccounts(policy); !reflect.DeepEqual(got, want) {
+		t.Errorf("policyAccounts() = %v, want %v", got, want)
+	}
+}
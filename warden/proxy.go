@@ -0,0 +1,21 @@
+package warden
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// parseProxy checks that raw is a proxy URL the HTTP transport can use,
+// one of http://, https:// or socks5:// with a host
+func parseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q, use a URL such as http://127.0.0.1:8080 or socks5://127.0.0.1:1080", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, use http, https or socks5", u.Scheme)
+	}
+}
@@ -0,0 +1,99 @@
+package warden
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestParseProxy(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{"http://127.0.0.1:8080", false},
+		{"https://proxy.example.com", false},
+		{"socks5://127.0.0.1:1080", false},
+		{"ftp://proxy.example.com", true},
+		{"127.0.0.1:8080", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseProxy(tt.raw); (err != nil) != tt.wantErr {
+			t.Errorf("parseProxy(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewRejectsProxyWithResolver(t *testing.T) {
+	if _, err := New(Options{Proxy: "http://127.0.0.1:8080", Resolver: "10.0.0.2"}); err == nil {
+		t.Error("New accepted a proxy and a resolver, want an error")
+	}
+}
+
+func TestGetBucketRegionThroughProxy(t *testing.T) {
+	var mu sync.Mutex
+	var connected []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connected = append(connected, r.Method+" "+r.Host)
+		mu.Unlock()
+		http.Error(w, "refused", http.StatusForbidden)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	if _, err := getBucketRegion(context.Background(), "bucket", nil, proxyURL, 5*time.Second, false); err == nil {
+		t.Fatal("lookup succeeded, want the proxy's refusal")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(connected) != 1 || connected[0] != "CONNECT bucket.s3.amazonaws.com:443" {
+		t.Errorf("proxy saw %q, want a CONNECT to bucket.s3.amazonaws.com:443", connected)
+	}
+}
+
+func TestLoadConfigProxy(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	// the fake answers requests forwarded to it as a plain HTTP proxy, so
+	// the endpoint's host is never resolved
+	fake := &fakeS3{publicBucket: true}
+	proxy := httptest.NewServer(fake)
+	defer proxy.Close()
+
+	s, err := New(Options{
+		Proxy:     proxy.URL,
+		Endpoint:  "http://s3.proxied.invalid",
+		PathStyle: true,
+		Anonymous: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := s.LoadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := s.clientFor(cfg).GetBucketAcl(context.Background(), &s3.GetBucketAclInput{Bucket: aws.String("bucket")})
+	if err != nil {
+		t.Fatalf("GetBucketAcl through the proxy: %v", err)
+	}
+	if !summarizeGrants(output.Grants).publicRead {
+		t.Errorf("grants = %+v, want the fake's public ACL", output.Grants)
+	}
+}
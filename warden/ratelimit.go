@@ -0,0 +1,41 @@
+package warden
+
+import (
+	"context"
+
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+// newRateLimiter returns a limiter allowing perSecond requests a second,
+// shared by every worker, or nil for no limit. The burst is a second's
+// worth of requests so that a rate below one still lets requests through.
+func newRateLimiter(perSecond float64) *rate.Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	burst := int(perSecond)
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), burst)
+}
+
+// addRateLimitMiddleware makes each attempt at an S3 call, retries
+// included, wait its turn under Rate
+func (s *Scanner) addRateLimitMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("S3WardenRateLimit", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+		}
+		return next.HandleFinalize(ctx, in)
+	}), "Retry", middleware.After)
+}
+
+// waitRate waits for a turn under Rate, for requests made outside the SDK
+func (s *Scanner) waitRate(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
+	}
+	return s.limiter.Wait(ctx)
+}
@@ -0,0 +1,62 @@
+package warden
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	if l := newRateLimiter(0); l != nil {
+		t.Errorf("newRateLimiter(0) = %v, want nil", l)
+	}
+	if got := newRateLimiter(0.5).Burst(); got != 1 {
+		t.Errorf("newRateLimiter(0.5) burst = %d, want 1", got)
+	}
+	if got := newRateLimiter(20).Burst(); got != 20 {
+		t.Errorf("newRateLimiter(20) burst = %d, want 20", got)
+	}
+}
+
+func TestRateLimitMiddlewareCoversRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	s, err := New(Options{Rate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// spend the burst so each attempt has to wait its turn
+	for i := 0; i < s.limiter.Burst(); i++ {
+		s.limiter.Allow()
+	}
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = 3
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		}),
+		APIOptions: []func(*middleware.Stack) error{s.addRateLimitMiddleware},
+	})
+
+	start := time.Now()
+	if _, err := client.GetBucketAcl(context.Background(), &s3.GetBucketAclInput{Bucket: aws.String("a")}); err == nil {
+		t.Fatal("GetBucketAcl succeeded against a failing server")
+	}
+	// three attempts at 10 a second
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("three attempts took %v, want at least 250ms", elapsed)
+	}
+}
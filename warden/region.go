@@ -0,0 +1,141 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+)
+
+// regionLookupTimeout bounds the region lookup when no CheckTimeout is set,
+// so a hung request can't hold a worker forever
+const regionLookupTimeout = 30 * time.Second
+
+// regionStatusError is a region lookup answered without the region header,
+// which S3 leaves out for a bucket that doesn't exist
+type regionStatusError struct {
+	status int
+}
+
+func (e *regionStatusError) Error() string {
+	return fmt.Sprintf("bucket region not found in headers, status %d", e.status)
+}
+
+// regionLookupFailure classifies why a region lookup failed, so that a
+// bucket that doesn't exist can be told apart from the network being down
+func regionLookupFailure(err error) string {
+	var statusErr *regionStatusError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound:
+		return "bucket does not exist"
+	case errors.As(err, &statusErr) && statusErr.status == http.StatusForbidden:
+		return "access denied"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("HTTP %d", statusErr.status)
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "DNS name not found"
+	case errors.As(err, &dnsErr):
+		return "DNS lookup failed"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timed out"
+	}
+	return "request failed"
+}
+
+// isRegionMismatch reports whether S3 rejected a request because it was
+// sent to the wrong region
+func isRegionMismatch(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "PermanentRedirect", "AuthorizationHeaderMalformed", "IllegalLocationConstraintException":
+			return true
+		}
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusMovedPermanently {
+		return true
+	}
+	return false
+}
+
+// expectedRegion finds the region in an AuthorizationHeaderMalformed
+// message, such as "the region 'us-east-1' is wrong; expecting 'eu-west-1'"
+var expectedRegion = regexp.MustCompile(`expecting '([a-z0-9-]+)'`)
+
+// regionFromError returns the region S3 named for the bucket in an error
+// response, normalized, or "" if it didn't name a valid one. The response
+// may come from any endpoint, so what it names is checked like any other
+// region before it is used to pick one.
+func regionFromError(err error) string {
+	var named string
+	var respErr *awshttp.ResponseError
+	var apiErr smithy.APIError
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		named = respErr.Response.Header.Get("X-Amz-Bucket-Region")
+	}
+	if named == "" && errors.As(err, &apiErr) && apiErr.ErrorCode() == "AuthorizationHeaderMalformed" {
+		if m := expectedRegion.FindStringSubmatch(apiErr.ErrorMessage()); m != nil {
+			named = m[1]
+		}
+	}
+	if named == "" {
+		return ""
+	}
+	region, _, err := normalizeRegion(named)
+	if err != nil {
+		return ""
+	}
+	return region
+}
+
+// isNoSuchBucket reports whether S3 said the bucket does not exist
+func isNoSuchBucket(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucket"
+}
+
+// knownRegions are the S3 regions published at the time of writing. New
+// regions appear regularly, so an unknown region is warned about rather
+// than rejected.
+var knownRegions = map[string]bool{
+	"us-east-1": true, "us-east-2": true, "us-west-1": true, "us-west-2": true,
+	"af-south-1": true, "ap-east-1": true, "ap-south-1": true, "ap-south-2": true,
+	"ap-southeast-1": true, "ap-southeast-2": true, "ap-southeast-3": true, "ap-southeast-4": true,
+	"ap-northeast-1": true, "ap-northeast-2": true, "ap-northeast-3": true,
+	"ca-central-1": true, "ca-west-1": true,
+	"eu-central-1": true, "eu-central-2": true, "eu-west-1": true, "eu-west-2": true, "eu-west-3": true,
+	"eu-north-1": true, "eu-south-1": true, "eu-south-2": true,
+	"il-central-1": true, "me-central-1": true, "me-south-1": true, "sa-east-1": true,
+	"us-gov-east-1": true, "us-gov-west-1": true,
+	"cn-north-1": true, "cn-northwest-1": true,
+}
+
+// regionPattern is the shape every AWS region code has, e.g. eu-west-1 or
+// us-gov-east-1
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// normalizeRegion lowercases a region and checks it. It fails for values
+// that can't be a region at all and flags well-formed but unfamiliar ones
+// as unknown.
+func normalizeRegion(region string) (normalized string, known bool, err error) {
+	normalized = strings.ToLower(strings.TrimSpace(region))
+	if !regionPattern.MatchString(normalized) {
+		return "", false, fmt.Errorf("invalid region %q", region)
+	}
+	return normalized, knownRegions[normalized], nil
+}
@@ -0,0 +1,180 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func responseError(status int) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      errors.New("request failed"),
+		},
+	}
+}
+
+func TestRegionLookupFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"not found", &regionStatusError{status: http.StatusNotFound}, "bucket does not exist"},
+		{"forbidden", &regionStatusError{status: http.StatusForbidden}, "access denied"},
+		{"other status", &regionStatusError{status: http.StatusBadGateway}, "HTTP 502"},
+		{"nxdomain", &url.Error{Op: "Head", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}}, "DNS name not found"},
+		{"dns server down", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, "DNS lookup failed"},
+		{"deadline", fmt.Errorf("lookup: %w", context.DeadlineExceeded), "timed out"},
+		{"client timeout", &url.Error{Op: "Head", Err: timeoutError{}}, "timed out"},
+		{"connection refused", errors.New("connection refused"), "request failed"},
+	}
+
+	for _, tt := range tests {
+		if got := regionLookupFailure(tt.err); got != tt.want {
+			t.Errorf("%s: regionLookupFailure() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestCountLookupFailure(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int64{1, 2, 3} {
+		if got := s.countLookupFailure("timed out"); got != want {
+			t.Errorf("failure %d counted as %d, want %d", i, got, want)
+		}
+	}
+	s.countLookupFailure("bucket does not exist")
+
+	stats := s.Stats()
+	if want := map[string]int64{"timed out": 3, "bucket does not exist": 1}; !reflect.DeepEqual(stats.RegionLookupFailures, want) {
+		t.Errorf("RegionLookupFailures = %v, want %v", stats.RegionLookupFailures, want)
+	}
+	stats.RegionLookupFailures["timed out"] = 0
+	if s.Stats().RegionLookupFailures["timed out"] != 3 {
+		t.Error("changing the stats changed the scanner's counts")
+	}
+}
+
+func TestIsRegionMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"permanent redirect", &smithy.GenericAPIError{Code: "PermanentRedirect"}, true},
+		{"wrong signing region", &smithy.GenericAPIError{Code: "AuthorizationHeaderMalformed"}, true},
+		{"wrapped redirect", fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "PermanentRedirect"}), true},
+		{"bare 301", responseError(http.StatusMovedPermanently), true},
+		{"access denied", &smithy.GenericAPIError{Code: "AccessDenied"}, false},
+		{"forbidden", responseError(http.StatusForbidden), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRegionMismatch(tt.err); got != tt.want {
+				t.Errorf("isRegionMismatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegionFromError(t *testing.T) {
+	redirect := responseError(http.StatusMovedPermanently).(*awshttp.ResponseError)
+	redirect.Response.Header = http.Header{"X-Amz-Bucket-Region": []string{"eu-west-1"}}
+
+	if got := regionFromError(fmt.Errorf("operation error: %w", redirect)); got != "eu-west-1" {
+		t.Errorf("regionFromError() = %q, want eu-west-1", got)
+	}
+	if got := regionFromError(responseError(http.StatusMovedPermanently)); got != "" {
+		t.Errorf("regionFromError() without the header = %q, want empty", got)
+	}
+	malformed := &smithy.GenericAPIError{
+		Code:    "AuthorizationHeaderMalformed",
+		Message: "The authorization header is malformed; the region 'us-east-1' is wrong; expecting 'ap-southeast-2'",
+	}
+	if got := regionFromError(malformed); got != "ap-southeast-2" {
+		t.Errorf("regionFromError() = %q, want ap-southeast-2", got)
+	}
+	if got := regionFromError(&smithy.GenericAPIError{Code: "PermanentRedirect"}); got != "" {
+		t.Errorf("regionFromError() without a response = %q, want empty", got)
+	}
+
+	redirect.Response.Header = http.Header{"X-Amz-Bucket-Region": []string{"EU-West-2"}}
+	if got := regionFromError(redirect); got != "eu-west-2" {
+		t.Errorf("regionFromError() = %q, want it normalized to eu-west-2", got)
+	}
+	redirect.Response.Header = http.Header{"X-Amz-Bucket-Region": []string{"evil.example.com/x"}}
+	if got := regionFromError(redirect); got != "" {
+		t.Errorf("regionFromError() with an invalid region = %q, want it dropped", got)
+	}
+}
+
+func TestIsNoSuchBucket(t *testing.T) {
+	if !isNoSuchBucket(&smithy.GenericAPIError{Code: "NoSuchBucket"}) {
+		t.Errorf("NoSuchBucket not recognised")
+	}
+	if isNoSuchBucket(&smithy.GenericAPIError{Code: "AccessDenied"}) || isNoSuchBucket(nil) {
+		t.Errorf("other errors treated as NoSuchBucket")
+	}
+}
+
+func TestNormalizeRegion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		known   bool
+		wantErr bool
+	}{
+		{"eu-west-1", "eu-west-1", true, false},
+		{" US-EAST-1 ", "us-east-1", true, false},
+		{"us-gov-west-1", "us-gov-west-1", true, false},
+		{"xx-future-9", "xx-future-9", false, false},
+		{"", "", false, true},
+		{"not a region", "", false, true},
+		{"eu-west", "", false, true},
+	}
+
+	for _, tt := range tests {
+		got, known, err := normalizeRegion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeRegion(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want || known != tt.known {
+			t.Errorf("normalizeRegion(%q) = %q, %v, want %q, %v", tt.in, got, known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestRegionLookupURL(t *testing.T) {
+	tests := map[string]string{
+		"bucket":          "https://bucket.s3.amazonaws.com",
+		"www.example.com": "https://s3.amazonaws.com/www.example.com",
+	}
+	for bucket, want := range tests {
+		if got := regionLookupURL(bucket); got != want {
+			t.Errorf("regionLookupURL(%q) = %q, want %q", bucket, got, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// newResolver returns a resolver that sends every DNS query to addr, given
+// as ip or ip:port with port 53 assumed
+func newResolver(addr string) (*net.Resolver, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, "53"
+	}
+	if net.ParseIP(host) == nil {
+		return nil, fmt.Errorf("invalid resolver %q, use ip or ip:port", addr)
+	}
+	server := net.JoinHostPort(host, port)
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}, nil
+}
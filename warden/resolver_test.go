@@ -0,0 +1,23 @@
+package warden
+
+import "testing"
+
+func TestNewResolver(t *testing.T) {
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{"10.0.0.2", false},
+		{"10.0.0.2:5353", false},
+		{"[fd00::53]:53", false},
+		{"fd00::53", false},
+		{"dns.example.com:53", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if _, err := newResolver(tt.addr); (err != nil) != tt.wantErr {
+			t.Errorf("newResolver(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+		}
+	}
+}
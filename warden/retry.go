@@ -0,0 +1,119 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// newRetryer builds the retryer shared by every S3 client. By default it is
+// the SDK's standard retryer; RetryOn5xxOnly puts a check in front of its
+// retryables so access denied and missing buckets fail on the first
+// attempt, while connection errors, timeouts and throttling are still
+// retried. Every retry is counted against the scan's retry budget.
+func (s *Scanner) newRetryer() aws.Retryer {
+	standard := retry.NewStandard(func(o *retry.StandardOptions) {
+		if s.opts.MaxAttempts > 0 {
+			o.MaxAttempts = s.opts.MaxAttempts
+		}
+		if s.opts.RetryOn5xxOnly {
+			o.Retryables = append([]retry.IsErrorRetryable{retry.IsErrorRetryableFunc(noRetryDenied)}, o.Retryables...)
+		}
+		o.RateLimiter = &budgetLimiter{budget: &s.retries, inner: o.RateLimiter}
+	})
+	return &throttleRetryer{Standard: standard, budget: &s.retries}
+}
+
+// noRetryDenied refuses to retry 403 and 404 responses, which won't change
+// on a second attempt, and leaves every other error to the retryables
+// after it
+func noRetryDenied(err error) aws.Ternary {
+	var respErr *awshttp.ResponseError
+	if !errors.As(err, &respErr) || isThrottled(err) {
+		return aws.UnknownTernary
+	}
+	switch respErr.HTTPStatusCode() {
+	case http.StatusForbidden, http.StatusNotFound:
+		return aws.FalseTernary
+	}
+	return aws.UnknownTernary
+}
+
+// throttleRetryer always retries throttling, with backoff, until the
+// attempts or the retry budget run out. The standard retryer's token bucket
+// drains quickly when S3 throttles a busy scan and then stops retrying, so
+// throttled requests would fail as if access had been denied.
+type throttleRetryer struct {
+	*retry.Standard
+	budget *retryBudget
+}
+
+func (r *throttleRetryer) GetRetryToken(ctx context.Context, opErr error) (func(error) error, error) {
+	if !isThrottled(opErr) {
+		return r.Standard.GetRetryToken(ctx, opErr)
+	}
+	if !r.budget.take() {
+		return nil, errRetryBudgetExhausted
+	}
+	return func(error) error { return nil }, nil
+}
+
+// addThrottleCountMiddleware counts the requests that were still throttled
+// once their retries ran out, whose checks are missing from the findings
+func (s *Scanner) addThrottleCountMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("S3WardenThrottleCount", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		if isThrottled(err) {
+			s.throttled.Add(1)
+		}
+		return out, metadata, err
+	}), middleware.After)
+}
+
+// errRetryBudgetExhausted fails a request that would otherwise be retried
+var errRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// retryBudget counts the retries made across the whole scan, and once
+// limit is reached refuses any more so the scan fails fast instead of
+// retrying indefinitely. A limit of zero only counts.
+type retryBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// take claims one retry and reports whether the budget allowed it
+func (b *retryBudget) take() bool {
+	for {
+		used := b.used.Load()
+		if b.limit > 0 && used >= b.limit {
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+1) {
+			return true
+		}
+	}
+}
+
+// budgetLimiter puts the retry budget in front of the SDK's own retry
+// token bucket
+type budgetLimiter struct {
+	budget *retryBudget
+	inner  retry.RateLimiter
+}
+
+func (l *budgetLimiter) GetToken(ctx context.Context, cost uint) (func() error, error) {
+	if !l.budget.take() {
+		return nil, errRetryBudgetExhausted
+	}
+	return l.inner.GetToken(ctx, cost)
+}
+
+func (l *budgetLimiter) AddTokens(tokens uint) error {
+	return l.inner.AddTokens(tokens)
+}
@@ -0,0 +1,109 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestRetryOn5xxOnly(t *testing.T) {
+	s, err := New(Options{RetryOn5xxOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	retryer := s.newRetryer()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"internal error", responseError(http.StatusInternalServerError), true},
+		{"service unavailable", responseError(http.StatusServiceUnavailable), true},
+		{"slow down", &smithy.GenericAPIError{Code: "SlowDown"}, true},
+		{"forbidden", responseError(http.StatusForbidden), false},
+		{"not found", responseError(http.StatusNotFound), false},
+		{"request timeout", &smithy.GenericAPIError{Code: "RequestTimeout"}, true},
+		{"connection reset", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, true},
+		{"dial failure", &net.OpError{Op: "dial", Err: errors.New("no route to host")}, true},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryer.IsErrorRetryable(tt.err); got != tt.want {
+				t.Errorf("IsErrorRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int64
+		takes int
+		want  int64
+	}{
+		{"unlimited", 0, 5, 5},
+		{"within the budget", 3, 2, 2},
+		{"exhausted", 3, 5, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &retryBudget{limit: tt.limit}
+			allowed := int64(0)
+			for i := 0; i < tt.takes; i++ {
+				if budget.take() {
+					allowed++
+				}
+			}
+			if allowed != tt.want || budget.used.Load() != tt.want {
+				t.Errorf("allowed %d retries, used %d, want %d", allowed, budget.used.Load(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryerStopsWhenBudgetExhausted(t *testing.T) {
+	s, err := New(Options{RetryBudget: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	retryer := s.newRetryer()
+	throttled := &smithy.GenericAPIError{Code: "SlowDown"}
+
+	if _, err := retryer.GetRetryToken(context.Background(), throttled); err != nil {
+		t.Fatalf("first retry refused: %v", err)
+	}
+	if _, err := retryer.GetRetryToken(context.Background(), throttled); !errors.Is(err, errRetryBudgetExhausted) {
+		t.Errorf("second retry error = %v, want the budget to be exhausted", err)
+	}
+}
+
+func TestRetryerKeepsRetryingThrottles(t *testing.T) {
+	s, err := New(Options{MaxAttempts: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	retryer := s.newRetryer()
+	if got := retryer.MaxAttempts(); got != 7 {
+		t.Errorf("MaxAttempts() = %d, want 7", got)
+	}
+
+	// the SDK's token bucket allows 100 retries before refusing, which a
+	// throttled scan would use up
+	for i := 0; i < 150; i++ {
+		if _, err := retryer.GetRetryToken(context.Background(), &smithy.GenericAPIError{Code: "SlowDown"}); err != nil {
+			t.Fatalf("throttled retry %d refused: %v", i, err)
+		}
+	}
+	if used := s.retries.used.Load(); used != 150 {
+		t.Errorf("counted %d retries, want 150", used)
+	}
+}
@@ -0,0 +1,226 @@
+package warden
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 serves just enough of the S3 REST API, over path-style URLs, to
+// exercise the checks against a real client
+type fakeS3 struct {
+	mu         sync.Mutex
+	keys       []string
+	publicKeys map[string]bool
+	// writableKeys are granted WRITE to everyone
+	writableKeys map[string]bool
+	// hungKeys never answer an ACL request, until the client gives up
+	hungKeys map[string]bool
+	// publicBucket and writableBucket grant READ and WRITE on the bucket
+	// to everyone, and bucketGrants are added to its ACL as they are
+	publicBucket, writableBucket bool
+	bucketGrants                 []string
+	aclChecks                    []string
+	// listed are the prefixes passed to each listing
+	listed []string
+	// failPrefix makes listings of this prefix fail
+	failPrefix string
+	// uploaded and deleted are the keys written and removed
+	uploaded, deleted []string
+	// aclPuts are the bodies of the ACLs written, "" for a canned ACL
+	aclPuts []string
+	// encryption is the default encryption algorithm, none when empty
+	encryption string
+	// versioning is the versioning status, never enabled when empty
+	versioning string
+	// website is the body of the website configuration, none when empty
+	website string
+	// missing answers every request with NoSuchBucket, and denied with
+	// AccessDenied
+	missing, denied bool
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+
+	switch {
+	case f.missing:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message></Error>`)
+	case f.denied:
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+	case r.Method == http.MethodGet && key == "" && query.Get("list-type") == "2":
+		f.mu.Lock()
+		f.listed = append(f.listed, query.Get("prefix"))
+		f.mu.Unlock()
+		if f.failPrefix != "" && query.Get("prefix") == f.failPrefix {
+			http.Error(w, "listing failed", http.StatusForbidden)
+			return
+		}
+		f.list(w, query.Get("prefix"), query.Get("delimiter"))
+	case r.Method == http.MethodGet && key == "" && query.Has("encryption"):
+		if f.encryption == "" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>ServerSideEncryptionConfigurationNotFoundError</Code><Message>The server side encryption configuration was not found</Message></Error>`)
+			return
+		}
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><ServerSideEncryptionConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>%s</SSEAlgorithm></ApplyServerSideEncryptionByDefault><BucketKeyEnabled>true</BucketKeyEnabled></Rule></ServerSideEncryptionConfiguration>`, f.encryption)
+	case r.Method == http.MethodGet && key == "" && query.Has("versioning"):
+		status := ""
+		if f.versioning != "" {
+			status = "<Status>" + f.versioning + "</Status>"
+		}
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">%s</VersioningConfiguration>`, status)
+	case r.Method == http.MethodGet && key == "" && query.Has("website"):
+		if f.website == "" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchWebsiteConfiguration</Code><Message>The specified bucket does not have a website configuration</Message></Error>`)
+			return
+		}
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">%s</WebsiteConfiguration>`, f.website)
+	case r.Method == http.MethodGet && key == "" && query.Has("acl"):
+		writeACL(w, f.publicBucket, f.writableBucket, f.bucketGrants...)
+	case r.Method == http.MethodPut && query.Has("acl"):
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.aclPuts = append(f.aclPuts, string(body))
+		f.mu.Unlock()
+	case r.Method == http.MethodGet && key != "" && query.Has("acl") && f.hungKeys[key]:
+		<-r.Context().Done()
+	case r.Method == http.MethodGet && key != "" && query.Has("acl"):
+		f.mu.Lock()
+		f.aclChecks = append(f.aclChecks, key)
+		public, writable := f.publicKeys[key], f.writableKeys[key]
+		f.mu.Unlock()
+		writeACL(w, public, writable)
+	case r.Method == http.MethodPut && key != "" && !query.Has("acl"):
+		f.mu.Lock()
+		f.uploaded = append(f.uploaded, key)
+		f.mu.Unlock()
+	case r.Method == http.MethodDelete && key != "":
+		f.mu.Lock()
+		f.deleted = append(f.deleted, key)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodHead && key != "":
+		if !f.has(key) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		http.Error(w, "unsupported "+r.Method+" "+bucket, http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string, delimiter string) {
+	var contents, prefixes []string
+	seen := map[string]bool{}
+	for _, key := range f.keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix)
+		if delimiter != "" {
+			if i := strings.Index(rest, delimiter); i >= 0 {
+				common := prefix + rest[:i+len(delimiter)]
+				if !seen[common] {
+					seen[common] = true
+					prefixes = append(prefixes, common)
+				}
+				continue
+			}
+		}
+		contents = append(contents, key)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+	fmt.Fprintf(&b, "<KeyCount>%d</KeyCount><IsTruncated>false</IsTruncated>", len(contents))
+	for _, key := range contents {
+		fmt.Fprintf(&b, "<Contents><Key>%s</Key><Size>1</Size></Contents>", key)
+	}
+	for _, common := range prefixes {
+		fmt.Fprintf(&b, "<CommonPrefixes><Prefix>%s</Prefix></CommonPrefixes>", common)
+	}
+	b.WriteString("</ListBucketResult>")
+	w.Write([]byte(b.String()))
+}
+
+func writeACL(w http.ResponseWriter, public bool, writable bool, extra ...string) {
+	grants := `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>owner</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant>`
+	if public {
+		grants += `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>` + AllUsersURI + `</URI></Grantee><Permission>READ</Permission></Grant>`
+	}
+	if writable {
+		grants += `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>` + AllUsersURI + `</URI></Grantee><Permission>WRITE</Permission></Grant>`
+	}
+	grants += strings.Join(extra, "")
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>owner</ID></Owner><AccessControlList>%s</AccessControlList></AccessControlPolicy>`, grants)
+}
+
+func (f *fakeS3) has(key string) bool {
+	for _, k := range f.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeS3) checked() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	checked := append([]string(nil), f.aclChecks...)
+	sort.Strings(checked)
+	return checked
+}
+
+// newFakeClient starts fake and returns an anonymous client pointed at it
+func newFakeClient(t *testing.T, fake *fakeS3) *s3.Client {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+}
+
+// newTestScanner returns a scanner whose findings are collected, along
+// with a function that returns them once the checks are done
+func newTestScanner(t *testing.T, opts Options) (*Scanner, func() []Finding) {
+	t.Helper()
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := make(chan Finding)
+	s.findings = findings
+	done := make(chan []Finding)
+	go func() {
+		var collected []Finding
+		for f := range findings {
+			collected = append(collected, f)
+		}
+		done <- collected
+	}()
+
+	return s, func() []Finding {
+		close(findings)
+		return <-done
+	}
+}
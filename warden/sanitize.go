@@ -0,0 +1,28 @@
+package warden
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Printable makes an untrusted bucket or key name safe to print. S3 keys may
+// hold any bytes and input lists can carry anything, so invalid UTF-8 and
+// non-printable characters, including terminal escape sequences, are
+// percent-encoded rather than written to the terminal as-is.
+func Printable(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if (r == utf8.RuneError && size == 1) || !unicode.IsPrint(r) || r == '%' {
+			for _, c := range []byte(name[i : i+size]) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package warden
+
+import "testing"
+
+func TestPrintable(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "logs/2024/app.log", "logs/2024/app.log"},
+		{"unicode kept", "données/résumé.pdf", "données/résumé.pdf"},
+		{"space kept", "my file.txt", "my file.txt"},
+		{"invalid utf8", "bad\xffkey", "bad%FFkey"},
+		{"control characters", "a\nb\tc", "a%0Ab%09c"},
+		{"escape sequence", "\x1b[31mred", "%1B[31mred"},
+		{"percent is escaped", "100%", "100%25"},
+		{"non-printable rune", "a​b", "a%E2%80%8Bb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Printable(tt.in); got != tt.want {
+				t.Errorf("Printable(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package warden
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SensitivePatterns are regular expressions for object keys that tend to
+// hold secrets, keys or backups, a starting point for Options.Sensitive
+var SensitivePatterns = []string{
+	`backup`,
+	`secret`,
+	`credential`,
+	`passw(or)?d`,
+	`(^|/)\.env($|\.)`,
+	`(^|/)\.git/`,
+	`(^|/)\.aws/`,
+	`(^|/)\.ssh/`,
+	`(^|/)\.htpasswd$`,
+	`id_(rsa|dsa|ecdsa|ed25519)`,
+	`\.(pem|key|p12|pfx|ppk|kdbx)$`,
+	`\.(sql|dump|bak)(\.gz|\.zip)?$`,
+	`\.tfstate`,
+	`wp-config\.php`,
+}
+
+// compileSensitive compiles the Sensitive patterns, matching them without
+// regard to case
+func compileSensitive(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sensitive pattern %q, %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// markSensitive flags an object finding whose key matches a Sensitive
+// pattern, raising it to at least HIGH since the object is likely to be
+// worse than most to have public
+func (s *Scanner) markSensitive(f *Finding) {
+	if f.Key == "" {
+		return
+	}
+	for _, re := range s.sensitive {
+		if re.MatchString(f.Key) {
+			f.Sensitive = true
+			switch f.Severity {
+			case "INFORMATIONAL", "LOW", "MEDIUM":
+				f.Severity = "HIGH"
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package warden
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDefaultSensitivePatterns(t *testing.T) {
+	s, err := New(Options{Sensitive: SensitivePatterns})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{
+		"db/Backup-2024.tar":     true,
+		"app/.env":               true,
+		"app/.env.production":    true,
+		"repo/.git/config":       true,
+		"home/.ssh/id_rsa":       true,
+		"keys/server.pem":        true,
+		"exports/users.sql.gz":   true,
+		"terraform.tfstate":      true,
+		"images/logo.png":        false,
+		"docs/environment.html":  false,
+		"assets/keyboard.svg":    false,
+		"reports/q1-summary.pdf": false,
+	} {
+		f := Finding{Key: key, Severity: "MEDIUM"}
+		s.markSensitive(&f)
+		if f.Sensitive != want {
+			t.Errorf("%s: Sensitive = %v, want %v", key, f.Sensitive, want)
+		}
+	}
+}
+
+func TestMarkSensitiveSeverity(t *testing.T) {
+	s, err := New(Options{Sensitive: []string{`secret`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		finding Finding
+		want    string
+		flagged bool
+	}{
+		{Finding{Key: "SECRETS.txt", Severity: "MEDIUM"}, "HIGH", true},
+		{Finding{Key: "secret", Severity: "CRITICAL"}, "CRITICAL", true},
+		{Finding{Key: "public.txt", Severity: "MEDIUM"}, "MEDIUM", false},
+		{Finding{Bucket: "secret-bucket", Severity: "MEDIUM"}, "MEDIUM", false},
+	}
+	for _, tt := range tests {
+		f := tt.finding
+		s.markSensitive(&f)
+		if f.Severity != tt.want || f.Sensitive != tt.flagged {
+			t.Errorf("%+v: got %s, sensitive %v, want %s, sensitive %v", tt.finding, f.Severity, f.Sensitive, tt.want, tt.flagged)
+		}
+	}
+}
+
+func TestSensitiveObjectReported(t *testing.T) {
+	client := newFakeClient(t, &fakeS3{keys: []string{"backup.sql"}, publicKeys: map[string]bool{"backup.sql": true}})
+	s, wait := newTestScanner(t, Options{Sensitive: SensitivePatterns})
+	s.checkObjectACL(context.Background(), client, "bucket", "backup.sql", nil, s.report)
+	findings := wait()
+
+	if len(findings) != 1 || !findings[0].Sensitive || findings[0].Severity != "HIGH" {
+		t.Errorf("findings = %+v, want a sensitive HIGH object-public-read", findings)
+	}
+}
+
+func TestNewRejectsBadSensitivePattern(t *testing.T) {
+	if _, err := New(Options{Sensitive: []string{"backup("}}); err == nil {
+		t.Error("New accepted a malformed sensitive pattern")
+	}
+}
@@ -0,0 +1,76 @@
+package warden
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+)
+
+// BucketStatus is what scanning a bucket showed about whether it exists,
+// which for guessed names is a result in itself
+type BucketStatus string
+
+const (
+	// BucketAccessible buckets allowed at least one of the checks
+	BucketAccessible BucketStatus = "accessible"
+	// BucketPrivate buckets exist but denied every check
+	BucketPrivate BucketStatus = "private"
+	// BucketExists buckets exist, and weren't checked further because of
+	// ExistsOnly
+	BucketExists BucketStatus = "exists"
+	// BucketNotFound names have no bucket
+	BucketNotFound BucketStatus = "not-found"
+	// BucketSkipped buckets were excluded, or exist but were left out by the
+	// tag filters
+	BucketSkipped BucketStatus = "skipped"
+	// BucketUnknown buckets couldn't be checked, such as when the network
+	// failed or the name couldn't be used
+	BucketUnknown BucketStatus = "unknown"
+)
+
+// isAccessDenied reports whether S3 refused a request, which it only does
+// for a bucket that exists
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied" {
+		return true
+	}
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusForbidden
+}
+
+// lookupStatus is the status of a bucket whose region lookup failed
+func lookupStatus(err error) BucketStatus {
+	if regionLookupFailure(err) == "bucket does not exist" {
+		return BucketNotFound
+	}
+	return BucketUnknown
+}
+
+// isExpectedFailure reports whether a failed call is what a private bucket,
+// or one without the configuration asked for, normally returns: access
+// denied, a missing key or a NoSuch error code
+func isExpectedFailure(err error) bool {
+	if isAccessDenied(err) {
+		return true
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.ErrorCode(), "NoSuch") {
+		return true
+	}
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound
+}
+
+// logFailure logs a failed API call with Infof when it was expected and
+// Errorf when it wasn't
+func (s *Scanner) logFailure(err error, format string, a ...any) {
+	if isExpectedFailure(err) {
+		s.log.Infof(format, a...)
+		return
+	}
+	s.log.Errorf(format, a...)
+}
@@ -0,0 +1,33 @@
+package warden
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestIsExpectedFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"access denied", &smithy.GenericAPIError{Code: "AccessDenied"}, true},
+		{"forbidden", responseError(http.StatusForbidden), true},
+		{"no such bucket policy", &smithy.GenericAPIError{Code: "NoSuchBucketPolicy"}, true},
+		{"missing key", responseError(http.StatusNotFound), true},
+		{"internal error", responseError(http.StatusInternalServerError), false},
+		{"slow down", &smithy.GenericAPIError{Code: "SlowDown"}, false},
+		{"plain", errors.New("connection reset by peer"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isExpectedFailure(tt.err); got != tt.want {
+				t.Errorf("isExpectedFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
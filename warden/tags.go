@@ -0,0 +1,51 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// getBucketTags returns the bucket's tags, treating a bucket without any
+// tags as an empty set rather than an error
+func getBucketTags(ctx context.Context, client *s3.Client, bucket string) (map[string]string, error) {
+	output, err := client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchTagSet" {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// matchTags applies the IncludeTags filters to a bucket's tags. A filter
+// of key=value needs that exact value, a bare key matches any value. mode
+// "any" needs one filter to match, "all" needs every filter to match.
+func matchTags(tags map[string]string, filters []string, mode string) bool {
+	for _, filter := range filters {
+		key, value, hasValue := strings.Cut(filter, "=")
+		actual, present := tags[key]
+		matched := present && (!hasValue || actual == value)
+
+		if mode == "any" && matched {
+			return true
+		}
+		if mode == "all" && !matched {
+			return false
+		}
+	}
+	return mode == "all"
+}
@@ -0,0 +1,30 @@
+package warden
+
+import "testing"
+
+func TestMatchTags(t *testing.T) {
+	tags := map[string]string{"env": "prod", "team": "data"}
+
+	tests := []struct {
+		name    string
+		filters []string
+		mode    string
+		want    bool
+	}{
+		{"all match", []string{"env=prod", "team=data"}, "all", true},
+		{"all with one miss", []string{"env=prod", "team=web"}, "all", false},
+		{"any with one match", []string{"env=dev", "team=data"}, "any", true},
+		{"any with no match", []string{"env=dev", "team=web"}, "any", false},
+		{"bare key", []string{"env"}, "all", true},
+		{"missing bare key", []string{"owner"}, "any", false},
+		{"empty value must match exactly", []string{"env="}, "all", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchTags(tags, tt.filters, tt.mode); got != tt.want {
+				t.Errorf("matchTags(%v, %s) = %v, want %v", tt.filters, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
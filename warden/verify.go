@@ -0,0 +1,83 @@
+package warden
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// verifiedKinds are the high-severity findings held back for a second check
+// when Verify is set
+var verifiedKinds = map[string]bool{
+	KindPublicWrite:       true,
+	KindWritableACP:       true,
+	KindObjectPublicWrite: true,
+	KindWritableObjectACP: true,
+}
+
+type pendingFindings struct {
+	mu       sync.Mutex
+	findings []Finding
+}
+
+// hold keeps a finding for verification and reports whether it was held
+func (s *Scanner) hold(f Finding) bool {
+	if !s.opts.Verify || !verifiedKinds[f.Kind] {
+		return false
+	}
+	s.pending.mu.Lock()
+	s.pending.findings = append(s.pending.findings, f)
+	s.pending.mu.Unlock()
+	return true
+}
+
+// verifyPending re-runs the check behind every held finding once the first
+// pass is done, and reports only those the second check agrees with
+func (s *Scanner) verifyPending(ctx context.Context) {
+	s.pending.mu.Lock()
+	held := s.pending.findings
+	s.pending.findings = nil
+	s.pending.mu.Unlock()
+
+	if len(held) == 0 {
+		return
+	}
+	cfg, err := s.LoadConfig(ctx)
+	if err != nil {
+		s.log.Errorf("Unable to load SDK config to verify findings, %v", err)
+		return
+	}
+
+	for _, f := range held {
+		cfg.Region = f.Region
+		if s.confirm(ctx, s.clientFor(cfg), f) {
+			s.findings <- f
+		} else {
+			s.log.Debugf("Dropping %s on %s, it was not confirmed by a second check", f.Kind, Printable(f.Bucket))
+		}
+	}
+}
+
+// confirm repeats the check that produced a finding
+func (s *Scanner) confirm(ctx context.Context, client *s3.Client, f Finding) bool {
+	switch f.Kind {
+	case KindPublicWrite:
+		output, err := client.GetBucketAcl(ctx, &s3.GetBucketAclInput{
+			Bucket: aws.String(f.Bucket),
+		})
+		return err == nil && summarizeGrants(output.Grants).publicWrite
+	case KindObjectPublicWrite:
+		output, err := client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+			Bucket: aws.String(f.Bucket),
+			Key:    aws.String(f.Key),
+		})
+		return err == nil && summarizeGrants(output.Grants).publicWrite
+	case KindWritableACP:
+		return s.writeBucketACP(ctx, client, f.Bucket) == nil
+	case KindWritableObjectACP:
+		return s.writeObjectACP(ctx, client, f.Bucket, f.Key) == nil
+	}
+	return false
+}
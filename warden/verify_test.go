@@ -0,0 +1,56 @@
+package warden
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHold(t *testing.T) {
+	tests := []struct {
+		name   string
+		verify bool
+		kind   string
+		want   bool
+	}{
+		{"verify off", false, "public-write", false},
+		{"public write", true, "public-write", true},
+		{"writable object acp", true, "writable-object-acp", true},
+		{"public read is not verified", true, "public-read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(Options{Verify: tt.verify})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.hold(Finding{Kind: tt.kind}); got != tt.want {
+				t.Errorf("hold(%s) = %v, want %v", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmObjectPublicWrite(t *testing.T) {
+	fake := &fakeS3{writableKeys: map[string]bool{"still-open": true}}
+	client := newFakeClient(t, fake)
+	s, err := New(Options{Verify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"still-open", true},
+		{"since-fixed", false},
+	}
+
+	for _, tt := range tests {
+		f := Finding{Bucket: "bucket", Key: tt.key, Kind: "object-public-write"}
+		if got := s.confirm(context.Background(), client, f); got != tt.want {
+			t.Errorf("confirm(%s) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// versioningText describes a bucket's versioning and MFA delete settings.
+// A bucket that never had versioning turned on reports no status at all.
+func versioningText(status types.BucketVersioningStatus, mfaDelete types.MFADeleteStatus) string {
+	versioning := "not enabled"
+	switch status {
+	case types.BucketVersioningStatusEnabled:
+		versioning = "enabled"
+	case types.BucketVersioningStatusSuspended:
+		versioning = "suspended"
+	}
+	mfa := "disabled"
+	if mfaDelete == types.MFADeleteStatusEnabled {
+		mfa = "enabled"
+	}
+	return fmt.Sprintf("versioning %s, MFA delete %s", versioning, mfa)
+}
+
+// checkVersioning reports whether a bucket keeps old versions of objects,
+// which lets overwritten or deleted data be recovered. It is informational
+// rather than a public access issue.
+func (s *Scanner) checkVersioning(ctx context.Context, client *s3.Client, bucket string) {
+	output, err := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		s.logFailure(err, "Failed to get the versioning status for %s", Printable(bucket))
+		return
+	}
+
+	s.report(Finding{
+		Bucket:   bucket,
+		Region:   client.Options().Region,
+		Kind:     KindVersioning,
+		Severity: "INFORMATIONAL",
+		Message:  fmt.Sprintf("Bucket %s has %s", Printable(bucket), versioningText(output.Status, output.MFADelete)),
+	})
+}
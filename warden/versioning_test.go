@@ -0,0 +1,41 @@
+package warden
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestVersioningText(t *testing.T) {
+	tests := []struct {
+		status types.BucketVersioningStatus
+		mfa    types.MFADeleteStatus
+		want   string
+	}{
+		{"", "", "versioning not enabled, MFA delete disabled"},
+		{types.BucketVersioningStatusEnabled, types.MFADeleteStatusDisabled, "versioning enabled, MFA delete disabled"},
+		{types.BucketVersioningStatusEnabled, types.MFADeleteStatusEnabled, "versioning enabled, MFA delete enabled"},
+		{types.BucketVersioningStatusSuspended, "", "versioning suspended, MFA delete disabled"},
+	}
+
+	for _, tt := range tests {
+		if got := versioningText(tt.status, tt.mfa); got != tt.want {
+			t.Errorf("versioningText(%q, %q) = %q, want %q", tt.status, tt.mfa, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersioning(t *testing.T) {
+	client := newFakeClient(t, &fakeS3{versioning: "Suspended"})
+	s, wait := newTestScanner(t, Options{})
+	s.checkVersioning(context.Background(), client, "bucket")
+
+	findings := wait()
+	if len(findings) != 1 || findings[0].Kind != "versioning" || findings[0].Severity != "INFORMATIONAL" {
+		t.Fatalf("findings = %+v, want one informational versioning finding", findings)
+	}
+	if want := "Bucket bucket has versioning suspended, MFA delete disabled"; findings[0].Message != want {
+		t.Errorf("message = %q, want %q", findings[0].Message, want)
+	}
+}
@@ -0,0 +1,995 @@
+// Package warden checks S3 buckets for public access. A Scanner takes a
+// stream of bucket names and returns a stream of findings, so the checks
+// can be embedded in other tools as well as run from the s3-warden CLI.
+package warden
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+// Options configures a Scanner. The zero value scans with one worker and
+// makes only read-only checks.
+type Options struct {
+	// Concurrency is the number of buckets scanned at once
+	Concurrency int
+
+	// Aggressive attempts to write to buckets and their ACLs
+	Aggressive bool
+
+	// DryRun logs the writes Aggressive would make instead of making them
+	DryRun bool
+
+	// NoCleanup leaves the object uploaded by Aggressive in the bucket as
+	// proof of write, instead of deleting it
+	NoCleanup bool
+
+	// Quick checks only the bucket ACL and for a directory listing
+	Quick bool
+
+	// Checks selects which of the Checks to run on each bucket, or all of
+	// them when empty or when it includes "all"
+	Checks []string
+
+	// ReportExisting reports buckets that exist but deny every check
+	ReportExisting bool
+
+	// ReportOwners reports the owner disclosed by each readable bucket ACL,
+	// and each account named by a bucket policy, as owner-disclosed
+	// findings. They are collected for Owners either way.
+	ReportOwners bool
+
+	// ExistsOnly only looks up each bucket's region, which S3 answers for
+	// any bucket that exists, and reports the ones that do without running
+	// any other checks
+	ExistsOnly bool
+
+	// Fanout is the number of top-level prefixes listed in parallel
+	Fanout int
+
+	// ObjectConcurrency is the number of object ACLs fetched at once within
+	// each bucket. Zero means one at a time.
+	ObjectConcurrency int
+
+	// MaxFindings is the number of objects with public access issues
+	// reported per bucket before moving on. Zero means 5, and a negative
+	// value checks every object. Outliers and publicly writable objects
+	// count as issues.
+	MaxFindings int
+
+	// ListingSize pages through each listable bucket to report how many
+	// objects it holds and their total size, at most Sample objects when
+	// that is set
+	ListingSize bool
+
+	// Sample is the number of objects checked per bucket before moving
+	// on, whatever was found. Zero checks every object, up to MaxFindings.
+	Sample int
+
+	// CountPublicRead counts publicly readable objects as issues too
+	CountPublicRead bool
+
+	// CountPastCap keeps checking a bucket's objects after MaxFindings
+	// issues have been reported, and reports how many there were in total
+	CountPastCap bool
+
+	// RegionGuess is assumed for every bucket, and a bucket's region is
+	// only looked up when S3 says the guess is wrong
+	RegionGuess string
+
+	// Region is used for every bucket without looking its region up, for
+	// networks that block the lookup. When S3 says a bucket is elsewhere
+	// and names its region, the call is retried there. It is also the
+	// region of the config LoadConfig returns.
+	Region string
+
+	// Keys, when set, are checked on each bucket instead of enumerating it
+	Keys []string
+
+	// ProbeCommonKeys checks CommonKeys on buckets that deny listing
+	ProbeCommonKeys bool
+
+	// ReportEncryption reports each bucket's default encryption, or that
+	// it has none, rather than only a misconfigured SSE-KMS setup
+	ReportEncryption bool
+
+	// Sensitive are regular expressions, matched case-insensitively, for
+	// object keys that are flagged as sensitive and raised to HIGH when
+	// public, such as SensitivePatterns
+	Sensitive []string
+
+	// Exclude names buckets that are never scanned, or written to in an
+	// aggressive scan, as bucket names or glob patterns such as prod-*
+	Exclude []string
+
+	// IncludeTags limits the scan to buckets with these tags, given as
+	// key=value or a bare key, matched according to TagMatch
+	IncludeTags []string
+	TagMatch    string
+
+	// RetryOn5xxOnly stops 403 and 404 responses from being retried
+	RetryOn5xxOnly bool
+
+	// MaxAttempts is the most times a request is tried, throttled ones
+	// included. Zero means the SDK's default of 3.
+	MaxAttempts int
+
+	// RetryBudget is the total number of retries allowed across the scan,
+	// after which failed requests are not retried. Zero means no limit.
+	RetryBudget int64
+
+	// Resolver is a DNS server, as ip or ip:port, used for the region lookup
+	// and S3 requests instead of the system resolver
+	Resolver string
+
+	// Prefix limits the enumeration of a bucket's objects to keys starting
+	// with it, filtered by S3 rather than listing the whole bucket. The
+	// bucket-level checks still run as usual.
+	Prefix string
+
+	// SkipPrefixes are object key prefixes left out when enumerating a
+	// bucket. Folders under a skipped prefix aren't listed at all.
+	SkipPrefixes []string
+
+	// BucketTimeout bounds all the checks on one bucket, after which the
+	// scan moves on to the next. Zero means no limit.
+	BucketTimeout time.Duration
+
+	// CheckTimeout limits each S3 call, and the region lookup, separately
+	// from the others. Zero means no limit, though the region lookup still
+	// gives up after 30 seconds.
+	CheckTimeout time.Duration
+
+	// Profile is a named profile in the shared AWS config and credentials
+	// files to load instead of the one AWS_PROFILE names, or the default
+	Profile string
+
+	// RoleARN is an IAM role assumed with the credentials found, such as
+	// one in a member account, whose credentials are used for the scan.
+	// ExternalID is passed when the role's trust policy requires one.
+	RoleARN    string
+	ExternalID string
+
+	// Anonymous sends unsigned requests, seeing buckets as someone outside
+	// the account would. It is also used when no credentials are found.
+	Anonymous bool
+
+	// Endpoint is the base URL of an S3-compatible store, such as MinIO,
+	// to scan instead of AWS. Buckets are then assumed to be in the
+	// configured region rather than looked up.
+	Endpoint string
+
+	// PathStyle addresses buckets as endpoint/bucket rather than as
+	// bucket.endpoint, which many S3-compatible stores need
+	PathStyle bool
+
+	// Insecure skips TLS certificate verification, for scanning through an
+	// intercepting proxy
+	Insecure bool
+
+	// Proxy is an http://, https:// or socks5:// URL that the region lookup
+	// and S3 requests are sent through, instead of any proxy set in the
+	// environment. The proxy resolves S3 hostnames itself.
+	Proxy string
+
+	// SlowThreshold warns about calls that take longer than this
+	SlowThreshold time.Duration
+
+	// LatencyStats keeps the duration of every call for Latencies
+	LatencyStats bool
+
+	// Verify holds back public write and writable ACP findings until the
+	// scan is done, then repeats each check and keeps only those it confirms
+	Verify bool
+
+	// BreakerThreshold is the number of throttled requests in a row,
+	// within BreakerWindow, that pauses the scan for BreakerCooldown and
+	// halves its workers. The workers are doubled again after 100
+	// successful requests in a row. Zero, the default here, disables the
+	// breaker; the command line turns it on at 20.
+	BreakerThreshold int
+	BreakerWindow    time.Duration
+	BreakerCooldown  time.Duration
+
+	// Rate caps the requests made across every worker, in requests per
+	// second, so many buckets can be in flight without going faster.
+	// Zero means no limit.
+	Rate float64
+
+	// Logger receives progress and failures. Nothing is logged when nil.
+	Logger Logger
+
+	// OnBucketDone is called as each bucket finishes
+	OnBucketDone func(bucket string)
+
+	// OnBucketStatus is called as each bucket finishes, before
+	// OnBucketDone, with whether it turned out to exist
+	OnBucketStatus func(bucket string, status BucketStatus)
+}
+
+// Logger receives the scan's log lines, without trailing newlines. Debugf
+// is for progress detail, Infof for API calls that failed as expected, such
+// as access denied by a private bucket, Warnf for problems worth showing
+// even when not debugging, and Errorf for API calls that failed otherwise.
+type Logger interface {
+	Debugf(format string, a ...any)
+	Infof(format string, a ...any)
+	Warnf(format string, a ...any)
+	Errorf(format string, a ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...any) {}
+func (nopLogger) Infof(string, ...any)  {}
+func (nopLogger) Warnf(string, ...any)  {}
+func (nopLogger) Errorf(string, ...any) {}
+
+// Target is a bucket to scan along with an optional region hint, which
+// saves a lookup when it is right
+type Target struct {
+	Bucket string
+	Region string
+}
+
+// Finding is a single issue discovered on a bucket or one of its objects.
+// Messages are safe to print, with untrusted names passed through
+// Printable.
+type Finding struct {
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+
+	// where a finding comes from a grant, what would need revoking
+	Grantee    string `json:"grantee,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	Source     string `json:"source,omitempty"`
+
+	// for object findings, whether the bucket itself is public
+	Scope string `json:"scope,omitempty"`
+
+	// for object findings, the object's content type when it is known,
+	// from S3 when the object was read and otherwise from its extension
+	ContentType string `json:"content_type,omitempty"`
+
+	// for owner-disclosed findings, the owner's canonical user ID or an
+	// account ID
+	Account string `json:"account,omitempty"`
+
+	// set on object findings whose key matches a Sensitive pattern
+	Sensitive bool `json:"sensitive,omitempty"`
+
+	// set on an open-listing finding when ListingSize is, with the count
+	// and size of the objects listed
+	ObjectCount *int64 `json:"object_count,omitempty"`
+	TotalSize   *int64 `json:"total_size,omitempty"`
+
+	// set on a scan-incomplete finding, so a bucket without findings isn't
+	// taken to be clean when its objects weren't all checked
+	Incomplete bool   `json:"incomplete,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// The kinds a Finding can have, which name the issue in JSON, CSV and
+// -print-only output and stay the same across releases
+const (
+	KindOpenListing              = "open-listing"
+	KindPublicRead               = "public-read"
+	KindPublicWrite              = "public-write"
+	KindAuthenticatedUsers       = "authenticated-users"
+	KindCrossAccountGrant        = "cross-account-grant"
+	KindLogDeliveryGrant         = "log-delivery-grant"
+	KindUploadAllowed            = "upload-allowed"
+	KindWritableACP              = "writable-acp"
+	KindWritableObjectACP        = "writable-object-acp"
+	KindObjectACLOutlier         = "object-acl-outlier"
+	KindObjectPublicRead         = "object-public-read"
+	KindObjectPublicWrite        = "object-public-write"
+	KindObjectAuthenticatedUsers = "object-authenticated-users"
+	KindObjectReadable           = "object-readable"
+	KindPolicyPublic             = "policy-public"
+	KindPolicyPublicWrite        = "policy-public-write"
+	KindPolicyVPCRestricted      = "policy-vpc-restricted"
+	KindObjectIssueCount         = "object-issue-count"
+	KindScanIncomplete           = "scan-incomplete"
+	KindKMSBucketKeyDisabled     = "kms-bucket-key-disabled"
+	KindEncryptionMissing        = "encryption-missing"
+	KindDefaultEncryption        = "default-encryption"
+	KindPublicAccessBlockMissing = "public-access-block-missing"
+	KindPublicAccessBlockPartial = "public-access-block-partial"
+	KindVersioning               = "versioning"
+	KindWebsiteEnabled           = "website-enabled"
+	KindExists                   = "exists"
+	KindOwnerDisclosed           = "owner-disclosed"
+)
+
+// Kinds lists every kind a Finding can have
+var Kinds = []string{
+	KindOpenListing,
+	KindPublicRead,
+	KindPublicWrite,
+	KindAuthenticatedUsers,
+	KindCrossAccountGrant,
+	KindLogDeliveryGrant,
+	KindUploadAllowed,
+	KindWritableACP,
+	KindWritableObjectACP,
+	KindObjectACLOutlier,
+	KindObjectPublicRead,
+	KindObjectPublicWrite,
+	KindObjectAuthenticatedUsers,
+	KindObjectReadable,
+	KindPolicyPublic,
+	KindPolicyPublicWrite,
+	KindPolicyVPCRestricted,
+	KindObjectIssueCount,
+	KindScanIncomplete,
+	KindKMSBucketKeyDisabled,
+	KindEncryptionMissing,
+	KindDefaultEncryption,
+	KindPublicAccessBlockMissing,
+	KindPublicAccessBlockPartial,
+	KindVersioning,
+	KindWebsiteEnabled,
+	KindExists,
+	KindOwnerDisclosed,
+}
+
+// CommonKeys are well-known object keys that often hold secrets or
+// backups, probed by ProbeCommonKeys
+var CommonKeys = []string{
+	".env",
+	".git/config",
+	"backup.zip",
+	"backup.tar.gz",
+	"backup.sql",
+	"config.json",
+	"credentials",
+	"database.sql",
+	"dump.sql",
+	"id_rsa",
+	"index.html",
+	"robots.txt",
+	"wp-config.php",
+}
+
+// Scanner runs the checks. A Scanner runs one Scan at a time, and keeps
+// the owners and latencies it sees across scans.
+type Scanner struct {
+	opts     Options
+	log      Logger
+	breaker  *breaker
+	limiter  *rate.Limiter
+	resolver *net.Resolver
+	proxy    *url.URL
+	findings chan<- Finding
+	pending  pendingFindings
+
+	bucketsScanned atomic.Int64
+	objectsChecked atomic.Int64
+	throttled      atomic.Int64
+	retries        retryBudget
+
+	ownersMu     sync.Mutex
+	owners       map[string]map[string]bool
+	bucketOwners map[string]string
+
+	latencyMu      sync.Mutex
+	latencySamples map[string][]time.Duration
+
+	configOnce sync.Once
+	config     aws.Config
+	configErr  error
+
+	clientsMu sync.Mutex
+	clients   map[string]*s3.Client
+
+	lookupFailuresMu sync.Mutex
+	lookupFailures   map[string]int64
+
+	// checks are the ones selected by Options.Checks, or nil for all
+	checks map[string]bool
+
+	// sensitive are the compiled Options.Sensitive patterns
+	sensitive []*regexp.Regexp
+}
+
+// New returns a Scanner for opts, or an error if the options are invalid
+func New(opts Options) (*Scanner, error) {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.TagMatch == "" {
+		opts.TagMatch = "all"
+	}
+	if opts.MaxFindings == 0 {
+		opts.MaxFindings = defaultMaxFindings
+	}
+	if opts.TagMatch != "all" && opts.TagMatch != "any" {
+		return nil, fmt.Errorf("invalid tag match %q, use all or any", opts.TagMatch)
+	}
+	if opts.Endpoint != "" {
+		if u, err := url.Parse(opts.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint %q, use a URL such as https://minio.example.com:9000", opts.Endpoint)
+		}
+	}
+	for _, prefix := range opts.SkipPrefixes {
+		if prefix == "" {
+			return nil, errors.New("empty skip prefix would skip every object")
+		}
+	}
+	if opts.ExistsOnly && opts.Endpoint != "" {
+		return nil, errors.New("checking buckets exist uses the AWS region lookup, which an endpoint doesn't answer")
+	}
+	if opts.ExistsOnly && opts.Region != "" {
+		return nil, errors.New("checking buckets exist uses the AWS region lookup, which a fixed region skips")
+	}
+	if opts.Region != "" && opts.RegionGuess != "" {
+		return nil, errors.New("a fixed region and a region guess can't be used together")
+	}
+	if opts.RoleARN != "" && opts.Anonymous {
+		return nil, errors.New("assuming a role needs credentials, which anonymous requests don't send")
+	}
+	if opts.ExternalID != "" && opts.RoleARN == "" {
+		return nil, errors.New("an external ID is only used when assuming a role")
+	}
+	if opts.Proxy != "" && opts.Resolver != "" {
+		return nil, errors.New("a proxy resolves S3 hostnames itself, so a resolver would only be used to find the proxy")
+	}
+	checks, err := checkSet(opts.Checks)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExcludes(opts.Exclude); err != nil {
+		return nil, err
+	}
+	sensitive, err := compileSensitive(opts.Sensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scanner{
+		opts:           opts,
+		log:            opts.Logger,
+		owners:         map[string]map[string]bool{},
+		bucketOwners:   map[string]string{},
+		latencySamples: map[string][]time.Duration{},
+		clients:        map[string]*s3.Client{},
+		lookupFailures: map[string]int64{},
+		checks:         checks,
+		sensitive:      sensitive,
+	}
+	s.retries.limit = opts.RetryBudget
+	if s.log == nil {
+		s.log = nopLogger{}
+	}
+
+	if opts.RegionGuess != "" {
+		normalized, known, err := normalizeRegion(opts.RegionGuess)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			s.log.Warnf("Warning: region guess %s is not a known region", normalized)
+		}
+		s.opts.RegionGuess = normalized
+	}
+	if opts.Region != "" {
+		normalized, known, err := normalizeRegion(opts.Region)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			s.log.Warnf("Warning: region %s is not a known region", normalized)
+		}
+		s.opts.Region = normalized
+	}
+
+	if opts.Resolver != "" {
+		resolver, err := newResolver(opts.Resolver)
+		if err != nil {
+			return nil, err
+		}
+		s.resolver = resolver
+	}
+	if opts.Proxy != "" {
+		proxy, err := parseProxy(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		s.proxy = proxy
+	}
+
+	if opts.BreakerThreshold > 0 {
+		s.breaker = newBreaker(opts.BreakerThreshold, opts.BreakerWindow, opts.BreakerCooldown, opts.Concurrency)
+	}
+	s.limiter = newRateLimiter(opts.Rate)
+	return s, nil
+}
+
+// LoadConfig returns the default AWS config with the scanner's retry and
+// middleware settings applied, and with anonymous credentials when
+// Anonymous is set or no credentials are configured. The config is loaded
+// once and each call gets a copy, so setting its Region is safe.
+func (s *Scanner) LoadConfig(ctx context.Context) (aws.Config, error) {
+	s.configOnce.Do(func() {
+		s.config, s.configErr = config.LoadDefaultConfig(ctx, s.configOptions()...)
+		var missing config.SharedConfigProfileNotExistError
+		if errors.As(s.configErr, &missing) {
+			s.configErr = fmt.Errorf("profile %q not found in the shared AWS config or credentials files", missing.Profile)
+		}
+		if s.configErr == nil {
+			s.configErr = s.applyCredentials(ctx, &s.config)
+		}
+	})
+	return s.config, s.configErr
+}
+
+// configOptions returns the SDK config options implied by the options
+func (s *Scanner) configOptions() []func(*config.LoadOptions) error {
+	options := []func(*config.LoadOptions) error{
+		config.WithRetryer(s.newRetryer),
+		config.WithAPIOptions([]func(*middleware.Stack) error{s.addThrottleCountMiddleware}),
+	}
+	if s.opts.Profile != "" {
+		options = append(options, config.WithSharedConfigProfile(s.opts.Profile))
+	}
+	if s.opts.Region != "" {
+		options = append(options, config.WithRegion(s.opts.Region))
+	}
+	if s.resolver != nil || s.opts.Insecure || s.proxy != nil {
+		client := awshttp.NewBuildableClient()
+		if s.resolver != nil {
+			client = client.WithDialerOptions(func(d *net.Dialer) {
+				d.Resolver = s.resolver
+			})
+		}
+		if s.opts.Insecure {
+			client = client.WithTransportOptions(func(tr *http.Transport) {
+				if tr.TLSClientConfig == nil {
+					tr.TLSClientConfig = &tls.Config{}
+				}
+				tr.TLSClientConfig.InsecureSkipVerify = true
+			})
+		}
+		if s.proxy != nil {
+			client = client.WithTransportOptions(func(tr *http.Transport) {
+				tr.Proxy = http.ProxyURL(s.proxy)
+			})
+		}
+		options = append(options, config.WithHTTPClient(client))
+	}
+	if s.opts.SlowThreshold > 0 || s.opts.LatencyStats {
+		options = append(options, config.WithAPIOptions([]func(*middleware.Stack) error{s.addLatencyMiddleware}))
+	}
+	if s.breaker != nil {
+		options = append(options, config.WithAPIOptions([]func(*middleware.Stack) error{s.addBreakerMiddleware}))
+	}
+	if s.limiter != nil {
+		options = append(options, config.WithAPIOptions([]func(*middleware.Stack) error{s.addRateLimitMiddleware}))
+	}
+	if s.opts.CheckTimeout > 0 {
+		options = append(options, config.WithAPIOptions([]func(*middleware.Stack) error{s.addCheckTimeoutMiddleware}))
+	}
+	return options
+}
+
+// Scan checks every target and returns the findings, closing the channel
+// once the targets channel is closed and every bucket is done
+func (s *Scanner) Scan(ctx context.Context, targets <-chan Target) <-chan Finding {
+	findings := make(chan Finding)
+	s.findings = findings
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range targets {
+				status := BucketSkipped
+				if s.excluded(t.Bucket) {
+					s.log.Debugf("Bucket %s is excluded, skipping", Printable(t.Bucket))
+				} else {
+					if s.breaker != nil {
+						s.breaker.acquire()
+					}
+					status = s.processBucket(ctx, t)
+					s.bucketsScanned.Add(1)
+					if s.breaker != nil {
+						s.breaker.release()
+					}
+				}
+				if s.opts.OnBucketStatus != nil {
+					s.opts.OnBucketStatus(t.Bucket, status)
+				}
+				if s.opts.OnBucketDone != nil {
+					s.opts.OnBucketDone(t.Bucket)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		s.verifyPending(ctx)
+		close(findings)
+	}()
+	return findings
+}
+
+// Stats counts the work done by a Scanner so far
+type Stats struct {
+	Buckets int64
+	Objects int64
+
+	// Retries made, out of RetryBudget when one was set
+	Retries     int64
+	RetryBudget int64
+
+	// Throttled counts requests that failed because S3 was still
+	// throttling once they had been retried
+	Throttled int64
+
+	// RegionLookupFailures counts the buckets whose region couldn't be
+	// looked up, by why, such as "bucket does not exist" or "timed out"
+	RegionLookupFailures map[string]int64
+}
+
+// Stats returns the number of buckets scanned, objects checked and retries
+// made
+func (s *Scanner) Stats() Stats {
+	return Stats{
+		Buckets: s.bucketsScanned.Load(),
+		Objects: s.objectsChecked.Load(),
+
+		Retries:     s.retries.used.Load(),
+		RetryBudget: s.retries.limit,
+		Throttled:   s.throttled.Load(),
+
+		RegionLookupFailures: s.lookupFailureCounts(),
+	}
+}
+
+// countLookupFailure counts a failed region lookup, returning how many
+// have now failed the same way
+func (s *Scanner) countLookupFailure(failure string) int64 {
+	s.lookupFailuresMu.Lock()
+	defer s.lookupFailuresMu.Unlock()
+
+	s.lookupFailures[failure]++
+	return s.lookupFailures[failure]
+}
+
+func (s *Scanner) lookupFailureCounts() map[string]int64 {
+	s.lookupFailuresMu.Lock()
+	defer s.lookupFailuresMu.Unlock()
+
+	counts := make(map[string]int64, len(s.lookupFailures))
+	for failure, count := range s.lookupFailures {
+		counts[failure] = count
+	}
+	return counts
+}
+
+func (s *Scanner) report(f Finding) {
+	s.markSensitive(&f)
+	if s.hold(f) {
+		return
+	}
+	s.findings <- f
+}
+
+func (s *Scanner) reportAll(findings []Finding) {
+	for _, f := range findings {
+		s.report(f)
+	}
+}
+
+// processBucket runs the checks on one bucket and returns what they showed
+// about whether it exists
+func (s *Scanner) processBucket(ctx context.Context, t Target) (status BucketStatus) {
+	status = BucketUnknown
+	bucketName, arnRegion, err := resolveBucket(t.Bucket)
+	if err != nil {
+		s.log.Warnf("Skipping %s, %v", Printable(t.Bucket), err)
+		return
+	}
+
+	if s.opts.BucketTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.BucketTimeout)
+		defer cancel()
+		defer func() {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				s.log.Debugf("Timed out scanning %s after %s, moving on", Printable(bucketName), s.opts.BucketTimeout)
+			}
+		}()
+	}
+
+	if s.opts.ExistsOnly {
+		return s.checkExists(ctx, bucketName, arnRegion)
+	}
+
+	cfg, err := s.LoadConfig(ctx)
+	if err != nil {
+		s.log.Errorf("Unable to load SDK config for %s, %v", Printable(bucketName), err)
+		return
+	}
+
+	// with a guess the lookup is deferred until S3 tells us the region is
+	// wrong, which saves a request for every bucket in the guessed region.
+	// A region given with the bucket in the input, or a fixed region, is
+	// treated the same way.
+	bucketRegion := s.opts.RegionGuess
+	if s.opts.Region != "" {
+		bucketRegion = s.opts.Region
+	}
+	if t.Region != "" {
+		if normalized, _, err := normalizeRegion(t.Region); err == nil {
+			bucketRegion = normalized
+		} else {
+			s.log.Errorf("Ignoring the region hint for %s, %v", Printable(bucketName), err)
+		}
+	}
+	regionGuessed := bucketRegion != ""
+	// an access point or endpoint is never redirected to another region
+	canRelocate := arnRegion == "" && s.opts.Endpoint == ""
+
+	// an access point ARN names its region, and it has no virtual host the
+	// region lookup could use
+	if arnRegion != "" {
+		bucketRegion = arnRegion
+		regionGuessed = false
+	}
+
+	// S3-compatible stores don't answer the region lookup, so the hint or
+	// configured region is used as is
+	if s.opts.Endpoint != "" {
+		if bucketRegion == "" {
+			bucketRegion = cfg.Region
+		}
+		if bucketRegion == "" {
+			bucketRegion = "us-east-1"
+		}
+		regionGuessed = false
+	}
+
+	if bucketRegion == "" {
+		bucketRegion, err = s.lookupRegion(ctx, bucketName)
+		if err != nil {
+			return lookupStatus(err)
+		}
+	}
+
+	cfg.Region = bucketRegion
+	client := s.clientFor(cfg)
+
+	// relocate moves to the bucket's real region when S3 rejected a call
+	// for being sent to the wrong one, and reports whether to retry it. The
+	// region S3 named is used if it gave one, and otherwise a guessed
+	// region is looked up. This happens at most once per bucket, as a
+	// looked up region can be wrong too for some legacy or new buckets.
+	relocated := false
+	relocate := func(err error) bool {
+		if relocated || !canRelocate || !isRegionMismatch(err) {
+			return false
+		}
+		relocated = true
+		if named := regionFromError(err); named != "" && named != bucketRegion {
+			s.log.Debugf("Bucket %s is in %s, not %s, retrying there", Printable(bucketName), named, bucketRegion)
+			bucketRegion = named
+		} else if regionGuessed && s.opts.Region == "" {
+			if bucketRegion, err = s.lookupRegion(ctx, bucketName); err != nil {
+				status = lookupStatus(err)
+				return false
+			}
+		} else {
+			s.log.Errorf("Bucket %s is not in %s and S3 didn't say where it is", Printable(bucketName), bucketRegion)
+			return false
+		}
+		cfg.Region = bucketRegion
+		client = s.clientFor(cfg)
+		regionGuessed = false
+		return true
+	}
+
+	if len(s.opts.IncludeTags) > 0 {
+		tags, err := getBucketTags(ctx, client, bucketName)
+		if relocate(err) {
+			tags, err = getBucketTags(ctx, client, bucketName)
+		}
+		if err != nil {
+			s.logFailure(err, "Unable to get tags for %s, skipping", Printable(bucketName))
+			return
+		}
+		if !matchTags(tags, s.opts.IncludeTags, s.opts.TagMatch) {
+			s.log.Debugf("Bucket %s does not match the tag filters, skipping", Printable(bucketName))
+			return BucketSkipped
+		}
+	}
+
+	// the ACL check is what confirms a guessed region, so without it the
+	// region is looked up instead, unless the region is fixed
+	var bucketACL aclSummary
+	aclReadable := false
+	if s.enabled(CheckACL) {
+		bucketACL, err = s.checkBucketACL(ctx, client, bucketName)
+		if relocate(err) {
+			bucketACL, err = s.checkBucketACL(ctx, client, bucketName)
+		}
+		// a guessed or given region, or an endpoint, skips the lookup that
+		// would otherwise have found the bucket missing
+		if isNoSuchBucket(err) {
+			s.log.Infof("Bucket %s does not exist", Printable(bucketName))
+			return BucketNotFound
+		}
+		aclReadable = err == nil
+	} else if regionGuessed && s.opts.Region == "" {
+		bucketRegion, err = s.lookupRegion(ctx, bucketName)
+		if err != nil {
+			return lookupStatus(err)
+		}
+		cfg.Region = bucketRegion
+		client = s.clientFor(cfg)
+	}
+
+	listable, keyCount := false, 0
+	if s.enabled(CheckListing) {
+		listable, keyCount = s.checkOpenListing(ctx, client, bucketName)
+	}
+
+	accessible := aclReadable || listable
+	if !s.opts.Quick {
+		if s.enabled(CheckPolicy) {
+			policyReadable := s.checkBucketPolicy(ctx, client, bucketName)
+			accessible = accessible || policyReadable
+		}
+		if s.enabled(CheckEncryption) {
+			s.checkBucketEncryption(ctx, client, bucketName)
+		}
+		if s.enabled(CheckPublicAccessBlock) {
+			s.checkPublicAccessBlock(ctx, client, bucketName)
+		}
+		if s.enabled(CheckVersioning) {
+			s.checkVersioning(ctx, client, bucketName)
+		}
+		if s.enabled(CheckWebsite) {
+			s.checkWebsite(ctx, client, bucketName)
+		}
+	}
+	if s.opts.Aggressive && !s.opts.Quick {
+		uploaded := s.testUpload(ctx, client, bucketName, "s3-warden-test.txt", strings.NewReader("s3-warden-test"))
+		acpWritable := s.putBucketACP(ctx, client, bucketName)
+		accessible = accessible || uploaded || acpWritable
+	}
+
+	status = BucketAccessible
+	if !accessible {
+		status = BucketPrivate
+	}
+
+	// the region lookup only succeeds for buckets that exist, so a bucket
+	// that refused every check is confirmed but locked down
+	if s.opts.ReportExisting && !accessible {
+		s.report(Finding{
+			Bucket:   bucketName,
+			Region:   bucketRegion,
+			Kind:     KindExists,
+			Severity: "INFORMATIONAL",
+			Message:  fmt.Sprintf("Bucket exists but denies access: %s", Printable(bucketName)),
+		})
+	}
+
+	if s.opts.Quick || !s.enabled(CheckObjects) {
+		return
+	}
+
+	// objects can only be compared against a bucket ACL we managed to read
+	var baseline *aclSummary
+	if aclReadable {
+		baseline = &bucketACL
+	}
+
+	if len(s.opts.Keys) > 0 {
+		s.checkKeys(ctx, client, bucketName, s.opts.Keys, baseline)
+		return
+	}
+
+	// a bucket that can't be listed may still serve well-known keys
+	if !listable && s.enabled(CheckListing) && s.opts.ProbeCommonKeys {
+		s.log.Debugf("Bucket %s denies listing, probing common keys", Printable(bucketName))
+		s.checkKeys(ctx, client, bucketName, CommonKeys, baseline)
+		return
+	}
+
+	// a listable bucket that returned no keys has nothing to enumerate
+	if listable && keyCount == 0 {
+		s.log.Debugf("Bucket %s is empty, skipping enumeration", Printable(bucketName))
+		return
+	}
+
+	s.iterateBucket(ctx, client, bucketName, baseline)
+	return
+}
+
+// checkExists reports a bucket if the region lookup finds it. An access
+// point has no virtual host to look up, so it can't be checked this way.
+func (s *Scanner) checkExists(ctx context.Context, bucket string, arnRegion string) BucketStatus {
+	if arnRegion != "" {
+		s.log.Warnf("Skipping %s, access points can't be checked for existence", Printable(bucket))
+		return BucketUnknown
+	}
+
+	region, err := s.lookupRegion(ctx, bucket)
+	if err != nil {
+		return lookupStatus(err)
+	}
+	s.report(Finding{
+		Bucket:   bucket,
+		Region:   region,
+		Kind:     KindExists,
+		Severity: "INFORMATIONAL",
+		Message:  fmt.Sprintf("Bucket exists: %s", Printable(bucket)),
+	})
+	return BucketExists
+}
+
+// BucketRegion looks up the region a bucket is in
+func (s *Scanner) BucketRegion(ctx context.Context, bucket string) (string, error) {
+	return s.lookupRegion(ctx, bucket)
+}
+
+// lookupRegion finds and logs the region of a bucket, timing the lookup
+func (s *Scanner) lookupRegion(ctx context.Context, bucketName string) (string, error) {
+	if err := s.waitRate(ctx); err != nil {
+		return "", err
+	}
+	lookupStart := time.Now()
+	timeout := s.opts.CheckTimeout
+	if timeout <= 0 {
+		timeout = regionLookupTimeout
+	}
+	bucketRegion, err := getBucketRegion(ctx, bucketName, s.resolver, s.proxy, timeout, s.opts.Insecure)
+	s.recordLatency("GetBucketRegion", bucketName, time.Since(lookupStart))
+	if err != nil {
+		// a bad input list or a network outage fails every lookup the same
+		// way, so only the first of each kind is logged and the rest counted
+		failure := regionLookupFailure(err)
+		if s.countLookupFailure(failure) == 1 {
+			s.logFailure(err, "Unable to get the region for %s, %s. Further lookups failing this way are counted in the stats", Printable(bucketName), failure)
+		}
+		return "", err
+	}
+
+	normalized, known, err := normalizeRegion(bucketRegion)
+	if err != nil {
+		s.log.Errorf("Unable to use the region of %s, %v", Printable(bucketName), err)
+		return "", err
+	}
+	if !known {
+		s.log.Warnf("Warning: bucket %s is in unknown region %s", Printable(bucketName), Printable(normalized))
+	}
+
+	s.log.Debugf("Bucket %s found in Region %s", Printable(bucketName), normalized)
+	return normalized, nil
+}
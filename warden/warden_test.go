@@ -0,0 +1,253 @@
+package warden
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLoadConfigReturnsCopies(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.LoadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Region = "eu-west-1"
+
+	// a later change to the environment isn't seen, since the config is
+	// only loaded once
+	t.Setenv("AWS_REGION", "ap-south-1")
+	second, err := s.LoadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Region != "us-east-1" {
+		t.Errorf("second LoadConfig region = %q, want the us-east-1 loaded first", second.Region)
+	}
+}
+
+func TestScanCustomEndpoint(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-2")
+
+	server := httptest.NewServer(&fakeS3{keys: []string{"a.txt"}})
+	defer server.Close()
+
+	s, err := New(Options{Quick: true, Anonymous: true, Endpoint: server.URL, PathStyle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := make(chan Target, 1)
+	targets <- Target{Bucket: "bucket"}
+	close(targets)
+
+	var findings []Finding
+	for f := range s.Scan(context.Background(), targets) {
+		findings = append(findings, f)
+	}
+	if len(findings) != 1 || findings[0].Kind != "open-listing" || findings[0].Region != "eu-west-2" {
+		t.Errorf("findings = %+v, want an open listing in the configured region", findings)
+	}
+}
+
+func TestScanSelectedChecks(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-2")
+
+	fake := &fakeS3{keys: []string{"a.txt"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := New(Options{Checks: []string{CheckEncryption}, ReportEncryption: true, Anonymous: true, Endpoint: server.URL, PathStyle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := make(chan Target, 1)
+	targets <- Target{Bucket: "bucket"}
+	close(targets)
+
+	var findings []Finding
+	for f := range s.Scan(context.Background(), targets) {
+		findings = append(findings, f)
+	}
+	if len(findings) != 1 || findings[0].Kind != "encryption-missing" {
+		t.Errorf("findings = %+v, want only the encryption check's finding", findings)
+	}
+	if len(fake.listed) != 0 || len(fake.aclChecks) != 0 {
+		t.Errorf("listed %v and checked ACLs of %v, want neither", fake.listed, fake.aclChecks)
+	}
+}
+
+func TestScanBucketStatus(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-2")
+
+	tests := []struct {
+		name string
+		fake *fakeS3
+		want BucketStatus
+	}{
+		{"listable", &fakeS3{keys: []string{"a.txt"}}, BucketAccessible},
+		{"denied", &fakeS3{denied: true}, BucketPrivate},
+		{"missing", &fakeS3{missing: true}, BucketNotFound},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(tt.fake)
+
+		var got BucketStatus
+		s, err := New(Options{
+			Quick:     true,
+			Anonymous: true,
+			Endpoint:  server.URL,
+			PathStyle: true,
+			OnBucketStatus: func(bucket string, status BucketStatus) {
+				got = status
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		targets := make(chan Target, 1)
+		targets <- Target{Bucket: "bucket"}
+		close(targets)
+		for range s.Scan(context.Background(), targets) {
+		}
+		server.Close()
+
+		if got != tt.want {
+			t.Errorf("%s: status = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSet(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{names: nil, want: nil},
+		{names: []string{"all"}, want: nil},
+		{names: []string{CheckACL, "all"}, want: nil},
+		{names: []string{CheckACL, CheckPolicy}, want: map[string]bool{CheckACL: true, CheckPolicy: true}},
+		{names: []string{"acls"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := checkSet(tt.names)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("checkSet(%v) = %v, %v, want %v", tt.names, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsInvalidEndpoint(t *testing.T) {
+	if _, err := New(Options{Endpoint: "minio:9000"}); err == nil {
+		t.Error("New accepted an endpoint without a scheme")
+	}
+}
+
+func TestNewFixedRegion(t *testing.T) {
+	s, err := New(Options{Region: "EU-West-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.opts.Region != "eu-west-1" {
+		t.Errorf("Region = %q, want eu-west-1", s.opts.Region)
+	}
+	if _, err := New(Options{Region: "eu-west-1", RegionGuess: "us-east-1"}); err == nil {
+		t.Error("New accepted a fixed region with a region guess")
+	}
+	if _, err := New(Options{Region: "eu-west-1", ExistsOnly: true}); err == nil {
+		t.Error("New accepted a fixed region with ExistsOnly")
+	}
+}
+
+func TestScanExclude(t *testing.T) {
+	fake := &fakeS3{keys: []string{"a.txt"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	var mu sync.Mutex
+	statuses := map[string]BucketStatus{}
+	s, err := New(Options{
+		Quick:     true,
+		Anonymous: true,
+		Endpoint:  server.URL,
+		PathStyle: true,
+		Exclude:   []string{"prod-*", "keep"},
+		OnBucketStatus: func(bucket string, status BucketStatus) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[bucket] = status
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := make(chan Target, 3)
+	for _, bucket := range []string{"prod-data", "keep", "test-data"} {
+		targets <- Target{Bucket: bucket}
+	}
+	close(targets)
+	var scanned []string
+	for f := range s.Scan(context.Background(), targets) {
+		scanned = append(scanned, f.Bucket)
+	}
+
+	want := map[string]BucketStatus{"prod-data": BucketSkipped, "keep": BucketSkipped, "test-data": BucketAccessible}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	for _, bucket := range scanned {
+		if bucket != "test-data" {
+			t.Errorf("found %s, want excluded buckets left alone", bucket)
+		}
+	}
+	if got := s.Stats().Buckets; got != 1 {
+		t.Errorf("Buckets = %d, want only the bucket not excluded", got)
+	}
+}
+
+func TestNewRejectsBadExclude(t *testing.T) {
+	if _, err := New(Options{Exclude: []string{"prod-["}}); err == nil {
+		t.Error("New accepted a malformed exclude pattern")
+	}
+}
+
+func TestKindsAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, kind := range Kinds {
+		if seen[kind] {
+			t.Errorf("kind %q is listed twice", kind)
+		}
+		seen[kind] = true
+	}
+}
+
+func TestLoadConfigFixedRegion(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	s, err := New(Options{Region: "EU-West-2", Anonymous: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := s.LoadConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Region != "eu-west-2" {
+		t.Errorf("Region = %q, want the fixed region eu-west-2", cfg.Region)
+	}
+}
@@ -0,0 +1,58 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// websiteText describes a website configuration by what it serves, or
+// where it sends requests when it redirects them all. The names are set by
+// the bucket owner, so they are made printable.
+func websiteText(output *s3.GetBucketWebsiteOutput) string {
+	if output.RedirectAllRequestsTo != nil {
+		return "redirecting all requests to " + Printable(aws.ToString(output.RedirectAllRequestsTo.HostName))
+	}
+	var parts []string
+	if output.IndexDocument != nil {
+		parts = append(parts, "index document "+Printable(aws.ToString(output.IndexDocument.Suffix)))
+	}
+	if output.ErrorDocument != nil {
+		parts = append(parts, "error document "+Printable(aws.ToString(output.ErrorDocument.Key)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkWebsite reports a bucket set up for static website hosting. These are
+// often public, and a website bucket that is deleted while DNS still points
+// at it can be taken over by whoever creates one with the same name.
+func (s *Scanner) checkWebsite(ctx context.Context, client *s3.Client, bucket string) {
+	output, err := client.GetBucketWebsite(ctx, &s3.GetBucketWebsiteInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchWebsiteConfiguration" {
+			return
+		}
+		s.logFailure(err, "Failed to get the website configuration for %s", Printable(bucket))
+		return
+	}
+
+	message := fmt.Sprintf("Bucket %s hosts a static website", Printable(bucket))
+	if text := websiteText(output); text != "" {
+		message += ", " + text
+	}
+	s.report(Finding{
+		Bucket:   bucket,
+		Region:   client.Options().Region,
+		Kind:     KindWebsiteEnabled,
+		Severity: "LOW",
+		Message:  message,
+	})
+}
@@ -0,0 +1,52 @@
+package warden
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestCheckWebsite(t *testing.T) {
+	tests := []struct {
+		website string
+		want    string
+	}{
+		{website: "", want: ""},
+		{
+			website: "<IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key>404.html</Key></ErrorDocument>",
+			want:    "Bucket bucket hosts a static website, index document index.html, error document 404.html",
+		},
+		{
+			website: "<RedirectAllRequestsTo><HostName>example.com</HostName></RedirectAllRequestsTo>",
+			want:    "Bucket bucket hosts a static website, redirecting all requests to example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		client := newFakeClient(t, &fakeS3{website: tt.website})
+		s, wait := newTestScanner(t, Options{})
+		s.checkWebsite(context.Background(), client, "bucket")
+
+		findings := wait()
+		switch {
+		case tt.want == "" && len(findings) != 0:
+			t.Errorf("findings = %+v, want none without a website configuration", findings)
+		case tt.want != "" && (len(findings) != 1 || findings[0].Kind != "website-enabled" || findings[0].Message != tt.want):
+			t.Errorf("findings = %+v, want one website-enabled finding saying %q", findings, tt.want)
+		}
+	}
+}
+
+func TestWebsiteTextPrintable(t *testing.T) {
+	output := &s3.GetBucketWebsiteOutput{
+		IndexDocument: &types.IndexDocument{Suffix: aws.String("index\x1b[2J.html")},
+		ErrorDocument: &types.ErrorDocument{Key: aws.String("404.html")},
+	}
+	want := "index document index%1B[2J.html, error document 404.html"
+	if got := websiteText(output); got != want {
+		t.Errorf("websiteText() = %q, want %q", got, want)
+	}
+}